@@ -10,11 +10,15 @@ package gwf
 
 import (
 	"fmt"
+	"html/template"
+	"io"
 	"log"
 	"net/http"
 	"net/http/pprof"
 	"net/url"
 	"os"
+	"runtime/debug"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -52,12 +56,63 @@ type Application struct {
 	// 404处理handler
 	notFound HandlerFunc
 
+	// 是否对路径存在但方法不匹配的请求响应405
+	handleMethodNotAllowed bool
+	// 405处理handler
+	methodNotAllowed HandlerFunc
+	// 自动响应OPTIONS请求的handler，为nil时响应204
+	optionsHandler HandlerFunc
+
+	// 是否修正path尾部的斜杠并重定向
+	redirectTrailingSlash bool
+	// 是否修正path中的..、//以及大小写并重定向
+	redirectFixedPath bool
+
 	// 客户端上传数据的最大内存占用量
 	maxMultipartMemory int64
+	// 请求body的最大字节数，小于等于0时不限制
+	maxBodySize int64
 
 	//是否开启静态资源路由
 	enableStaticFileServer bool
 	fileServer             http.Handler
+
+	//是否开启路由表查看接口
+	enableRouteTable bool
+
+	// controller包路径的根，RegisterDynamicRouter生成url时去掉此前缀
+	controllerRoot string
+
+	// 依赖容器，通过Provide注册依赖
+	container container
+
+	// 参数校验，第一次使用时创建
+	validatorOnce sync.Once
+	validator     *structValidator
+
+	// 按MIME类型注册的Renderer，为nil时使用默认的Renderer
+	renderers *renderers
+
+	// contextPool 复用Context以及responseWriter，减少每个请求的内存分配
+	contextPool sync.Pool
+}
+
+// RouteDesc 路由的描述信息，用于查看应用提供的所有路由
+type RouteDesc struct {
+	// Method http方法
+	Method string `json:"method"`
+	// Host 路由组绑定的host，未绑定时为空
+	Host string `json:"host"`
+	// Path 包含路由组前缀的完整路由
+	Path string `json:"path"`
+	// Name 路由名称，未命名时为空
+	Name string `json:"name"`
+	// Group 路由组名称
+	Group string `json:"group"`
+	// Handler 主逻辑handler的名称
+	Handler string `json:"handler"`
+	// Middlewares 执行主逻辑handler之前依次执行的middleware名称
+	Middlewares []string `json:"middlewares"`
 }
 
 var application *Application
@@ -81,10 +136,12 @@ func createApplication() *Application {
 	}
 
 	app := &Application{
-		config:             appConfig,
-		Logger:             logger,
-		notFound:           DefaultNotFoundHandler,
-		maxMultipartMemory: defaultMultipartMemory,
+		config:                appConfig,
+		Logger:                logger,
+		notFound:              DefaultNotFoundHandler,
+		methodNotAllowed:      DefaultMethodNotAllowedHandler,
+		redirectTrailingSlash: true,
+		maxMultipartMemory:    defaultMultipartMemory,
 	}
 	app.RouterGroup = NewRouterGroup(app, APP_DEFAULT_ROUTER_GROUP_NAME)
 
@@ -101,44 +158,227 @@ func (app *Application) SetNotFound(handler HandlerFunc) {
 	app.notFound = handler
 }
 
+// EnableMethodNotAllowed 开启405响应
+// 开启后，如果请求的path在其他http方法下注册过，将响应405并在Allow响应头中列出允许的方法
+func (app *Application) EnableMethodNotAllowed() {
+	app.handleMethodNotAllowed = true
+}
+
+// SetMethodNotAllowed 设置405的处理器，调用处理器时Allow响应头已经设置好
+func (app *Application) SetMethodNotAllowed(handler HandlerFunc) {
+	app.methodNotAllowed = handler
+}
+
+// SetOptionsHandler 设置自动响应OPTIONS请求的处理器
+// 没有显式注册OPTIONS路由时，框架根据已注册的方法设置Allow响应头，然后调用此处理器
+// 可以在此处理器中实现CORS预检请求的响应:
+//  app.SetOptionsHandler(func(c *gwf.Context) {
+//  	c.Header("Access-Control-Allow-Origin", "*")
+//  	c.Header("Access-Control-Allow-Methods", c.Writer.Header().Get("Allow"))
+//  	c.AbortWithStatus(http.StatusNoContent)
+//  })
+func (app *Application) SetOptionsHandler(handler HandlerFunc) {
+	app.optionsHandler = handler
+}
+
+// SetControllerRoot 设置controller包路径的根，RegisterDynamicRouter生成url时会去掉包路径中的此前缀
+// 比如设置为github.com/foo/bar/pkg/controller，github.com/foo/bar/pkg/controller/api中的
+// UserController的InfoAction对应的url为/api/user/info
+// 没有设置时与之前版本一致，去掉gopkg.babytree-inc.com/组/项目/pkg/controller/前缀，其他包路径在url中保持完整
+// controller也可以通过ControllerPather接口或者path标签自定义路径
+func (app *Application) SetControllerRoot(root string) {
+	app.controllerRoot = root
+}
+
 // SetMaxMultipartMemory 设置客户端上传数据的最大内存占用量
 func (app *Application) SetMaxMultipartMemory(n int64) {
 	app.maxMultipartMemory = n
 }
 
+// SetMaxBodySize 设置请求body的最大字节数，默认不限制，小于等于0时不限制
+// 超过限制时读取参数会失败，ParseForm以及Bind系列函数返回的*ParseError的Code是413
+func (app *Application) SetMaxBodySize(n int64) {
+	app.maxBodySize = n
+}
+
+// maxBodyReader 超过大小限制时返回ErrBodyTooLarge，http.MaxBytesReader返回的错误没有导出的类型可以判断
+type maxBodyReader struct {
+	io.ReadCloser
+	read  int64
+	limit int64
+}
+
+func newMaxBodyReader(w http.ResponseWriter, body io.ReadCloser, limit int64) io.ReadCloser {
+	// 使用http.MaxBytesReader，超过限制时服务端会关闭连接
+	return &maxBodyReader{ReadCloser: http.MaxBytesReader(w, body, limit), limit: limit}
+}
+
+func (r *maxBodyReader) Read(p []byte) (n int, err error) {
+	n, err = r.ReadCloser.Read(p)
+	r.read += int64(n)
+	if err != nil && err != io.EOF && r.read >= r.limit {
+		err = ErrBodyTooLarge
+	}
+	return
+}
+
+// recoverParseError 将ParamXXX、FormXXX等函数panic的*ParseError转换为400或者413响应，其他panic继续抛出
+// 重新抛出之后net/http记录的调用栈从这里开始，所以先记录panic发生位置的调用栈
+func (app *Application) recoverParseError(c *Context) {
+	if err := recover(); err != nil {
+		parseErr, ok := err.(*ParseError)
+		if !ok {
+			if err != http.ErrAbortHandler {
+				app.Logger.Printf("panic url:%s err:%v stack:%s", c.Request.URL.Path, err, debug.Stack())
+			}
+			panic(err)
+		}
+		app.Logger.Printf("解析请求参数失败 url:%s err:%s", c.Request.URL.Path, parseErr)
+		c.AbortWithStatusString(parseErr.Code, http.StatusText(parseErr.Code))
+	}
+}
+
 // ServeHttp实现了http.Handler接口
 func (app *Application) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	st := time.Now()
-	context := newCtx(app, r)
+	if app.maxBodySize > 0 && r.Body != nil {
+		r.Body = newMaxBodyReader(w, r.Body, app.maxBodySize)
+	}
+	c := app.acquireContext(w, r)
+	defer app.releaseContext(c, st)
+	defer app.recoverParseError(c)
+
+	if app.handleRequestByHost(c) {
+		return
+	}
+	if app.redirectRequest(c) {
+		return
+	}
+	if app.handleImplicitRequest(c) {
+		return
+	}
+	//静态资源
+	if app.enableStaticFileServer && strings.HasPrefix(r.URL.Path, app.appNamePrefix+"/public/") {
+		r2 := copyRequest(r)
+		app.fileServer.ServeHTTP(newW, r2)
+		return
+	}
+	app.notFound(c)
+}
+
+// acquireContext 从contextPool中取出Context并重置为当前请求的状态
+func (app *Application) acquireContext(w http.ResponseWriter, r *http.Request) *Context {
+	c, _ := app.contextPool.Get().(*Context)
+	if c == nil {
+		c = &Context{}
+	}
+	c.app = app
+	c.resetWriter(w)
+	c.reset(r)
+	return c
+}
 
-	writer := NewResponseWriter(w, ResponseStatusHandler(func(status int) int {
+// releaseContext 记录异常的响应状态码，然后将Context放回contextPool
+// 请求结束后Context会被其他请求复用，需要在其他goroutine中使用时请使用c.Copy()
+func (app *Application) releaseContext(c *Context, st time.Time) {
+	if status := c.Writer.Status(); c.Writer.Written() && status != http.StatusOK {
 		costTime := time.Since(st).Milliseconds()
-		if status == http.StatusOK {
-			return http.StatusOK
+		app.Logger.Printf("异常http状态码记录 status code:%d cost time(ms):%d url:%s", status, costTime, c.Request.URL.Path)
+	}
+	app.contextPool.Put(c)
+}
+
+// URLFor 生成命名路由的url，路由组前缀已经包含在生成的url中
+// params用于填充路由中的路径参数，其余的项将作为url参数
+//  app.GET("/user/:id", showUser).Name("user.show")
+//  url, err := app.URLFor("user.show", map[string]interface{}{"id": 1, "tab": "posts"}) // url为/user/1?tab=posts
+func (app *Application) URLFor(name string, params map[string]interface{}) (string, error) {
+	for _, rg := range app.routerGroups() {
+		if routeInfo, ok := rg.names[name]; ok {
+			return routeInfo.URL(params)
+		}
+	}
+	return "", fmt.Errorf("命名路由不存在 name:%s", name)
+}
+
+// 按匹配顺序返回所有的路由组
+func (app *Application) routerGroups() []*RouterGroup {
+	return append([]*RouterGroup{app.RouterGroup}, app.otherRouterGroups...)
+}
+
+// handleImplicitRequest 处理没有匹配到路由的请求:
+// HEAD请求使用GET路由处理，不输出响应body
+// OPTIONS请求根据已注册的方法响应Allow头
+// 开启405响应时，path在其他方法下注册过的请求响应405
+func (app *Application) handleImplicitRequest(c *Context) bool {
+	path := c.Request.URL.Path
+	groups, hostParams := app.hostRouterGroups(requestHost(c.Request))
+	switch c.Request.Method {
+	case http.MethodHead:
+		for i, rg := range groups {
+			if routeInfo, params := rg.match(http.MethodGet, path); routeInfo != nil {
+				c.HostParams = hostParams[i]
+				c.resetWriter(discardBodyWriter{c.writermem.ResponseWriter})
+				handleRoute(c, routeInfo, params)
+				return true
+			}
+		}
+	case http.MethodOptions:
+		allow := allowedMethods(groups, path)
+		if len(allow) == 0 {
+			return false
+		}
+		c.Header("Allow", strings.Join(allow, ", "))
+		if app.optionsHandler != nil {
+			app.optionsHandler(c)
 		} else {
-			app.Logger.Printf("异常http状态码记录 status code:%d cost time(ms):%d url:%s", status, costTime, r.URL.Path)
+			c.AbortWithStatus(http.StatusNoContent)
 		}
-		return status
-	}), nil, nil)
+		return true
+	}
 
-	context.Writer = writer
+	if app.handleMethodNotAllowed {
+		if allow := allowedMethods(groups, path); len(allow) > 0 {
+			c.Header("Allow", strings.Join(allow, ", "))
+			app.methodNotAllowed(c)
+			return true
+		}
+	}
+	return false
+}
 
-	if app.handleRequest(context) {
-		return
-	} else {
-		for _, rg := range app.otherRouterGroups {
-			if rg.handleRequest(context) {
-				return
+// allowedMethods 返回path在groups中注册过的http方法
+// 注册了GET时自动包含HEAD，有任意方法时自动包含OPTIONS
+func allowedMethods(groups []*RouterGroup, path string) []string {
+	registered := make(map[string]bool)
+	for _, rg := range groups {
+		for method := range rg.trees {
+			if routeInfo, _ := rg.match(method, path); routeInfo != nil {
+				registered[method] = true
 			}
 		}
 	}
-	//静态资源
-	if app.enableStaticFileServer && strings.HasPrefix(r.URL.Path, app.appNamePrefix+"/public/") {
-		r2 := copyRequest(r)
-		app.fileServer.ServeHTTP(newW, r2)
-		return
+	if len(registered) == 0 {
+		return nil
+	}
+	if registered[http.MethodGet] {
+		registered[http.MethodHead] = true
 	}
-	app.notFound(context)
+	registered[http.MethodOptions] = true
+
+	allow := make([]string, 0, len(registered))
+	for _, method := range httpMethods {
+		if registered[method] {
+			allow = append(allow, method)
+			delete(registered, method)
+		}
+	}
+	others := make([]string, 0, len(registered))
+	for method := range registered {
+		others = append(others, method)
+	}
+	sort.Strings(others)
+	return append(allow, others...)
 }
 
 // AddRouterGroup 添加额外的路由组，app初始化时会默认添加app级别路由组
@@ -146,6 +386,38 @@ func (app *Application) AddRouterGroup(rg *RouterGroup) {
 	app.otherRouterGroups = append(app.otherRouterGroups, rg)
 }
 
+// AllRoutes 返回应用中所有路由组注册的路由，顺序与请求匹配路由组的顺序一致
+// 同一路由组中的路由按path排序，path相同时按http方法排序
+func (app *Application) AllRoutes() []RouteDesc {
+	var routes []RouteDesc
+	for _, rg := range app.routerGroups() {
+		var routeInfos []*RouteInfo
+		for _, m := range rg.Routes {
+			for _, routeInfo := range m {
+				routeInfos = append(routeInfos, routeInfo)
+			}
+		}
+		sort.Slice(routeInfos, func(i, j int) bool {
+			if routeInfos[i].path != routeInfos[j].path {
+				return routeInfos[i].path < routeInfos[j].path
+			}
+			return methodOrder(routeInfos[i].method) < methodOrder(routeInfos[j].method)
+		})
+		for _, routeInfo := range routeInfos {
+			routes = append(routes, routeInfo.desc())
+		}
+	}
+	return routes
+}
+
+// EnableRouteTable 开启路由表查看接口，ops可以通过此接口核对线上服务提供的路由
+// 与pprof一样使用appname作为path前缀:
+//  /{appname}/internal/debug/routes 以html表格展示
+//  /{appname}/internal/debug/routes/json 以json格式展示
+func (app *Application) EnableRouteTable() {
+	app.enableRouteTable = true
+}
+
 // EnableStaticFileServer 开启静态文件服务器，可以基于public目录提供静态文件服务
 func (app *Application) EnableStaticFileServer() {
 	app.enableStaticFileServer = true
@@ -156,6 +428,7 @@ func (app *Application) EnableStaticFileServer() {
 // Start启动App, 此方法会监听配置的端口，提供服务
 // 此方法会阻塞主协程
 // 路由设置一定要在此方法之前设定，否则不生效
+// 启动之前会检查所有路由组的路由表以及controller依赖的注入，存在冲突或者缺少依赖时panic，详见CheckRoutes和CheckProviders
 func (app *Application) Start() {
 	app.Logger.Printf("start app %s %s at %s ...", app.config.Name, app.config.Version, app.config.Addr)
 
@@ -163,6 +436,17 @@ func (app *Application) Start() {
 
 	app.addPprof()
 
+	if app.enableRouteTable {
+		app.addRouteTable()
+	}
+
+	if err := app.CheckRoutes(); err != nil {
+		panic(err.Error())
+	}
+	if err := app.CheckProviders(); err != nil {
+		panic(err.Error())
+	}
+
 	server := &http.Server{
 		Addr:     app.config.Addr,
 		Handler:  http.HandlerFunc(app.ServeHTTP),
@@ -214,6 +498,41 @@ func (app *Application) addPprof() {
 	app.AddRouterGroup(rg)
 }
 
+var routeTableTemplate = template.Must(template.New("routes").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Name}} routes</title></head>
+<body>
+<h3>{{.Name}} {{.Version}} 共{{len .Routes}}个路由</h3>
+<table border="1" cellspacing="0" cellpadding="4">
+<tr><th>Method</th><th>Host</th><th>Path</th><th>Name</th><th>Group</th><th>Handler</th><th>Middlewares</th></tr>
+{{range .Routes}}<tr><td>{{.Method}}</td><td>{{.Host}}</td><td>{{.Path}}</td><td>{{.Name}}</td><td>{{.Group}}</td><td>{{.Handler}}</td><td>{{range .Middlewares}}{{.}}<br>{{end}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// 增加路由表查看接口
+func (app *Application) addRouteTable() {
+	rg := NewRouterGroup(app, "route_table")
+	rg.EnableAppNameAsPathPrefix()
+	rg.GET("/internal/debug/routes", func(c *Context) {
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		c.Status(http.StatusOK)
+		err := routeTableTemplate.Execute(c.Writer, map[string]interface{}{
+			"Name":    app.config.Name,
+			"Version": app.config.Version,
+			"Routes":  app.AllRoutes(),
+		})
+		if err != nil {
+			app.Logger.Printf("渲染路由表失败 err:%s", err)
+		}
+	})
+	rg.GET("/internal/debug/routes/json", func(c *Context) {
+		c.Json(http.StatusOK, app.AllRoutes())
+	})
+	app.AddRouterGroup(rg)
+}
+
 // 复制http.Request，来源于net/http包的StripPrefix方法
 func copyRequest(r *http.Request) *http.Request {
 	r2 := new(http.Request)
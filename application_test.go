@@ -4,10 +4,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"log"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"path"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -112,6 +116,228 @@ func TestNotFound(t *testing.T) {
 	assert.Equal(t, "资源不存在", string(b))
 }
 
+func TestMethodNotAllowed(t *testing.T) {
+	app := newTestApplication()
+	app.GET("/user/:id", func(c *Context) {
+		c.String(http.StatusOK, "user")
+	})
+	app.DELETE("/user/:id", func(c *Context) {})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("PUT", "/user/1", nil)
+	app.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	app.EnableMethodNotAllowed()
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "GET, HEAD, DELETE, OPTIONS", w.Header().Get("Allow"))
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("PUT", "/nothing", nil)
+	app.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestImplicitHeadAndOptions(t *testing.T) {
+	app := newTestApplication()
+	app.GET("/hello", func(c *Context) {
+		c.Header("X-Hello", "world")
+		c.String(http.StatusOK, "hello")
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("HEAD", "/hello", nil)
+	app.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "world", w.Header().Get("X-Hello"))
+	assert.Empty(t, w.Body.String())
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("OPTIONS", "/hello", nil)
+	app.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "GET, HEAD, OPTIONS", w.Header().Get("Allow"))
+
+	app.SetOptionsHandler(func(c *Context) {
+		c.Header("Access-Control-Allow-Methods", c.Writer.Header().Get("Allow"))
+		c.AbortWithStatus(http.StatusOK)
+	})
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "GET, HEAD, OPTIONS", w.Header().Get("Access-Control-Allow-Methods"))
+}
+
+func TestContextPool(t *testing.T) {
+	app := newTestApplication()
+	var cp *Context
+	used := make(map[*Context]bool)
+	app.GET("/user/:id", func(c *Context) {
+		// 复用的Context不能带有上一个请求的状态
+		_, exists := c.Get("user")
+		assert.False(t, exists)
+		assert.Equal(t, "", c.QueryString("name"))
+		c.Set("user", c.Params.ByName("id"))
+		if cp == nil {
+			cp = c.Copy()
+		}
+		used[c] = true
+		c.String(http.StatusOK, c.Params.ByName("id"))
+	})
+	app.GET("/search", func(c *Context) {
+		assert.Nil(t, c.Params)
+		c.String(http.StatusCreated, c.QueryString("name"))
+	})
+
+	reused := false
+	for i := 1; i <= 10 && !reused; i++ {
+		id := strconv.Itoa(i)
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/user/"+id, nil)
+		n := len(used)
+		app.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, id, w.Body.String())
+		// sync.Pool不保证复用，开启-race时会随机丢弃放回的对象
+		reused = i > 1 && len(used) == n
+	}
+	assert.True(t, reused)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/search?name=gwf", nil)
+	app.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "gwf", w.Body.String())
+
+	// 副本不受之后复用的Context影响
+	assert.False(t, used[cp])
+	assert.Equal(t, "1", cp.Params.ByName("id"))
+	user, _ := cp.Get("user")
+	assert.Equal(t, "1", user)
+	assert.True(t, cp.IsAborted())
+}
+
+func TestContextCopyAfterRequest(t *testing.T) {
+	app := newTestApplication()
+	done := make(chan struct{})
+	result := make(chan interface{}, 1)
+	app.POST("/mail", func(c *Context) {
+		// 读取参数之前复制，请求结束之后才读取
+		cp := c.Copy()
+		go func() {
+			defer func() {
+				if err := recover(); err != nil {
+					result <- err
+				}
+			}()
+			<-done
+			result <- cp.ParamString("email")
+		}()
+		c.String(http.StatusAccepted, "ok")
+	})
+	server := httptest.NewServer(app)
+	defer server.Close()
+
+	resp, err := http.PostForm(server.URL+"/mail", url.Values{"email": {"gwf@example.com"}})
+	if assert.Nil(t, err) {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	}
+	close(done)
+	assert.Equal(t, "gwf@example.com", <-result)
+}
+
+// BenchmarkServeHTTP 对比复用Context与每个请求重新分配Context的内存分配
+func BenchmarkServeHTTP(b *testing.B) {
+	app := newTestApplication()
+	app.GET("/hello", func(c *Context) {
+		c.String(http.StatusOK, "hello world")
+	})
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/hello", nil)
+
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			w.Body.Reset()
+			app.ServeHTTP(w, r)
+		}
+	})
+	b.Run("unpooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			w.Body.Reset()
+			// 清空contextPool，每个请求都重新分配Context和responseWriter
+			app.contextPool = sync.Pool{}
+			app.ServeHTTP(w, r)
+		}
+	})
+}
+
+func TestURLFor(t *testing.T) {
+	app := newTestApplication()
+	app.GET("/user/:id", func(_ *Context) {}).Name("user.show")
+	rg := NewRouterGroup(app, "admin")
+	rg.EnableAppNameAsPathPrefix()
+	rg.Group("/admin").GET("/article/:id/edit", func(_ *Context) {}).Name("article.edit")
+	app.AddRouterGroup(rg)
+
+	u, err := app.URLFor("user.show", map[string]interface{}{"id": 3})
+	assert.Nil(t, err)
+	assert.Equal(t, "/user/3", u)
+
+	u, err = app.URLFor("article.edit", map[string]interface{}{"id": "x/y"})
+	assert.Nil(t, err)
+	assert.Equal(t, "/gwf/admin/article/x%2Fy/edit", u)
+
+	_, err = app.URLFor("not.exists", nil)
+	assert.NotNil(t, err)
+}
+
+func routeTableMiddleware(c *Context) {
+	c.Next()
+}
+
+func routeTableHandler(c *Context) {
+	c.String(http.StatusOK, "ok")
+}
+
+func TestAllRoutes(t *testing.T) {
+	app := newTestApplication()
+	app.POST("/b", routeTableHandler)
+	app.GET("/b", routeTableMiddleware, routeTableHandler).Name("b")
+	app.GET("/a", routeTableHandler)
+	app.addRouteTable()
+
+	routes := app.AllRoutes()
+	assert.Equal(t, 5, len(routes))
+	assert.Equal(t, RouteDesc{
+		Method:      "GET",
+		Path:        "/b",
+		Name:        "b",
+		Group:       AppDefaultRouterGroupName,
+		Handler:     "github.com/panda-win/gwf.routeTableHandler",
+		Middlewares: []string{"github.com/panda-win/gwf.routeTableMiddleware"},
+	}, routes[1])
+	assert.Equal(t, "POST", routes[2].Method)
+	assert.Equal(t, "/gwf/internal/debug/routes", routes[3].Path)
+	assert.Equal(t, "route_table", routes[3].Group)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/gwf/internal/debug/routes/json", nil)
+	app.ServeHTTP(w, r)
+	var res []RouteDesc
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, routes, res)
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("GET", "/gwf/internal/debug/routes", nil)
+	app.ServeHTTP(w, r)
+	assert.Contains(t, w.Body.String(), "github.com/panda-win/gwf.routeTableMiddleware")
+}
+
 func createAppServer(t *testing.T) *httptest.Server {
 
 	ts := createTestServer(func(w http.ResponseWriter, r *http.Request) {
@@ -171,6 +397,19 @@ func createAppServer(t *testing.T) *httptest.Server {
 	return ts
 }
 
+func newTestApplication() *Application {
+	app := &Application{
+		config:                &applicationConfig{Name: "gwf"},
+		Logger:                log.New(ioutil.Discard, "gwf: ", log.Lshortfile),
+		notFound:              DefaultNotFoundHandler,
+		methodNotAllowed:      DefaultMethodNotAllowedHandler,
+		redirectTrailingSlash: true,
+		maxMultipartMemory:    defaultMultipartMemory,
+	}
+	app.RouterGroup = NewRouterGroup(app, AppDefaultRouterGroupName)
+	return app
+}
+
 func createTestServer(fn func(w http.ResponseWriter, r *http.Request)) *httptest.Server {
 	return httptest.NewServer(http.HandlerFunc(fn))
 }
@@ -0,0 +1,277 @@
+package gwf
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Lifetime 依赖的生命周期
+type Lifetime uint8
+
+const (
+	// Singleton 整个应用只创建一次
+	Singleton Lifetime = iota
+	// PerRequest 每个请求创建一次，同一个请求中多次获取得到的是同一个值
+	PerRequest
+)
+
+// injectTagName controller中需要注入依赖的字段的tag
+const injectTagName = "inject"
+
+var applicationPtrType = reflect.TypeOf(&Application{})
+
+// provider 依赖的构造函数以及生命周期
+type provider struct {
+	constructor reflect.Value
+	lifetime    Lifetime
+
+	// singleton的值，resolved为true时有效
+	mu       sync.Mutex
+	resolved bool
+	value    reflect.Value
+}
+
+// container 依赖容器，以构造函数返回值的类型作为key
+type container struct {
+	mu        sync.RWMutex
+	providers map[reflect.Type]*provider
+}
+
+// Provide 注册依赖的构造函数，lifetime默认是Singleton
+// 构造函数的返回值是依赖本身，或者是依赖和error，参数是构造函数依赖的其他依赖，会从容器中获取
+// 参数可以是*gwf.Application，PerRequest的构造函数参数还可以是*gwf.Context
+//  app.Provide(func(conf *Config) (*sql.DB, error) {
+//  	return sql.Open("mysql", conf.DSN)
+//  })
+//  app.Provide(func(c *gwf.Context, db *sql.DB) *UserRepo {
+//  	return &UserRepo{db: db, traceID: c.Request.Header.Get("X-Trace-Id")}
+//  }, gwf.PerRequest)
+// 重复注册同一类型时，后注册的构造函数会替换之前的，测试中可以用来替换依赖
+// 依赖可以通过controller中有`inject:""`tag的导出字段、Context.Resolve以及Application.Resolve获取
+func (app *Application) Provide(constructor interface{}, lifetime ...Lifetime) {
+	ctor := reflect.ValueOf(constructor)
+	if ctor.Kind() != reflect.Func {
+		panic("constructor必须是函数")
+	}
+	ctorType := ctor.Type()
+	if ctorType.NumOut() == 0 || ctorType.NumOut() > 2 || (ctorType.NumOut() == 2 && ctorType.Out(1) != errorType) {
+		panic("constructor的返回值必须是T或者(T, error) constructor:" + ctorType.String())
+	}
+	if len(lifetime) > 1 {
+		panic("lifetime参数错误")
+	}
+
+	p := &provider{constructor: ctor, lifetime: Singleton}
+	if len(lifetime) == 1 {
+		p.lifetime = lifetime[0]
+	}
+	if p.lifetime == Singleton {
+		for i := 0; i < ctorType.NumIn(); i++ {
+			if ctorType.In(i) == contextPtrType {
+				panic("Singleton的constructor参数不能是*gwf.Context constructor:" + ctorType.String())
+			}
+		}
+	}
+
+	app.container.mu.Lock()
+	defer app.container.mu.Unlock()
+	if app.container.providers == nil {
+		app.container.providers = make(map[reflect.Type]*provider)
+	}
+	app.container.providers[ctorType.Out(0)] = p
+}
+
+// Resolve 从容器中获取依赖并赋值给ptr指向的变量，只能获取Singleton的依赖
+//  var db *sql.DB
+//  err := app.Resolve(&db)
+func (app *Application) Resolve(ptr interface{}) error {
+	return app.resolveInto(ptr, nil)
+}
+
+// Resolve 从容器中获取依赖并赋值给ptr指向的变量，PerRequest的依赖在同一个请求中只创建一次
+func (c *Context) Resolve(ptr interface{}) error {
+	return c.app.resolveInto(ptr, c)
+}
+
+func (app *Application) resolveInto(ptr interface{}, c *Context) error {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return errors.New("Resolve的参数必须是非nil的指针")
+	}
+	value, err := app.resolve(v.Type().Elem(), c, nil)
+	if err != nil {
+		return err
+	}
+	v.Elem().Set(value)
+	return nil
+}
+
+// resolve 获取类型t的依赖，c为nil时只能获取Singleton的依赖，resolving是正在创建的依赖，用于检查循环依赖
+func (app *Application) resolve(t reflect.Type, c *Context, resolving []reflect.Type) (reflect.Value, error) {
+	switch t {
+	case applicationPtrType:
+		return reflect.ValueOf(app), nil
+	case contextPtrType:
+		if c == nil {
+			return reflect.Value{}, errors.New("*gwf.Context只能在请求中获取")
+		}
+		return reflect.ValueOf(c), nil
+	}
+
+	app.container.mu.RLock()
+	p := app.container.providers[t]
+	app.container.mu.RUnlock()
+	if p == nil {
+		return reflect.Value{}, fmt.Errorf("类型%s没有注册", t)
+	}
+	for _, r := range resolving {
+		if r == t {
+			return reflect.Value{}, fmt.Errorf("类型%s存在循环依赖", t)
+		}
+	}
+	resolving = append(resolving, t)
+
+	if p.lifetime == Singleton {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.resolved {
+			return p.value, nil
+		}
+		// Singleton只能依赖Singleton
+		value, err := app.construct(p, nil, resolving)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		p.value = value
+		p.resolved = true
+		return value, nil
+	}
+
+	if c == nil {
+		return reflect.Value{}, fmt.Errorf("PerRequest类型%s只能在请求中获取", t)
+	}
+	if value, ok := c.scoped[t]; ok {
+		return value, nil
+	}
+	value, err := app.construct(p, c, resolving)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if c.scoped == nil {
+		c.scoped = make(map[reflect.Type]reflect.Value)
+	}
+	c.scoped[t] = value
+	return value, nil
+}
+
+// CheckProviders 检查已注册的controller中inject字段依赖的类型是否都可以获取，包括构造函数参数依赖的类型
+// 依赖可以在注册controller之后再注册，所以在Start启动服务之前调用此方法，缺少依赖时直接panic，
+// 避免在第一次请求对应的action时才发现
+func (app *Application) CheckProviders() error {
+	var errs []string
+	checked := make(map[reflect.Type]bool)
+	for _, rg := range app.routerGroups() {
+		for _, m := range rg.Routes {
+			for _, routeInfo := range m {
+				ai := routeInfo.invoker
+				if ai == nil || checked[ai.controllerType] {
+					continue
+				}
+				checked[ai.controllerType] = true
+				for _, field := range ai.injects {
+					if err := app.checkProvided(field.typ, false, nil); err != nil {
+						errs = append(errs, fmt.Sprintf("注入依赖失败 controller:%s field:%s err:%s", ai.controllerType, field.name, err))
+					}
+				}
+			}
+		}
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return errors.New(strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+// checkProvided 检查类型t以及构造函数依赖的类型是否已经注册，不会调用构造函数
+// singleton为true时t是Singleton的依赖，不能依赖PerRequest的类型
+func (app *Application) checkProvided(t reflect.Type, singleton bool, resolving []reflect.Type) error {
+	switch t {
+	case applicationPtrType:
+		return nil
+	case contextPtrType:
+		if singleton {
+			return errors.New("*gwf.Context只能在请求中获取")
+		}
+		return nil
+	}
+
+	app.container.mu.RLock()
+	p := app.container.providers[t]
+	app.container.mu.RUnlock()
+	if p == nil {
+		return fmt.Errorf("类型%s没有注册", t)
+	}
+	if singleton && p.lifetime == PerRequest {
+		return fmt.Errorf("PerRequest类型%s只能在请求中获取", t)
+	}
+	for _, r := range resolving {
+		if r == t {
+			return fmt.Errorf("类型%s存在循环依赖", t)
+		}
+	}
+	resolving = append(resolving, t)
+
+	ctorType := p.constructor.Type()
+	for i := 0; i < ctorType.NumIn(); i++ {
+		if err := app.checkProvided(ctorType.In(i), p.lifetime == Singleton, resolving); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// construct 获取构造函数的参数并调用构造函数
+func (app *Application) construct(p *provider, c *Context, resolving []reflect.Type) (reflect.Value, error) {
+	ctorType := p.constructor.Type()
+	in := make([]reflect.Value, ctorType.NumIn())
+	for i := range in {
+		value, err := app.resolve(ctorType.In(i), c, resolving)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		in[i] = value
+	}
+	out := p.constructor.Call(in)
+	if len(out) == 2 && !out[1].IsNil() {
+		return reflect.Value{}, fmt.Errorf("创建%s失败 err:%w", ctorType.Out(0), out[1].Interface().(error))
+	}
+	return out[0], nil
+}
+
+// injectField controller中需要注入依赖的字段
+type injectField struct {
+	index []int
+	name  string
+	typ   reflect.Type
+}
+
+// injectFields 返回controller中有inject tag的字段，未导出的字段无法注入，直接panic
+func injectFields(controllerType reflect.Type) []injectField {
+	var fields []injectField
+	for i := 0; i < controllerType.NumField(); i++ {
+		field := controllerType.Field(i)
+		if _, ok := field.Tag.Lookup(injectTagName); !ok {
+			continue
+		}
+		if field.PkgPath != "" {
+			panic("inject字段必须是导出的 controller:" + controllerType.String() + " field:" + field.Name)
+		}
+		fields = append(fields, injectField{index: field.Index, name: field.Name, typ: field.Type})
+	}
+	return fields
+}
@@ -0,0 +1,150 @@
+package gwf
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testDB struct {
+	dsn string
+}
+
+type testRepo struct {
+	db   *testDB
+	path string
+}
+
+type testCache interface {
+	Get(key string) string
+}
+
+type mapCache map[string]string
+
+func (m mapCache) Get(key string) string {
+	return m[key]
+}
+
+func TestContainer(t *testing.T) {
+	app := newTestApplication()
+	dbCount := 0
+	app.Provide(func() *testDB {
+		dbCount++
+		return &testDB{dsn: "mysql"}
+	})
+	app.Provide(func(c *Context, db *testDB) *testRepo {
+		return &testRepo{db: db, path: c.Request.URL.Path}
+	}, PerRequest)
+	app.Provide(func() (testCache, error) {
+		return mapCache{"k": "v"}, nil
+	})
+
+	var db1, db2 *testDB
+	assert.Nil(t, app.Resolve(&db1))
+	assert.Nil(t, app.Resolve(&db2))
+	assert.True(t, db1 == db2)
+	assert.Equal(t, 1, dbCount)
+
+	var cache testCache
+	assert.Nil(t, app.Resolve(&cache))
+	assert.Equal(t, "v", cache.Get("k"))
+
+	var repo *testRepo
+	assert.Error(t, app.Resolve(&repo))
+	var notProvided *string
+	assert.Error(t, app.Resolve(&notProvided))
+	assert.Error(t, app.Resolve(repo))
+
+	c := &Context{app: app, Request: httptest.NewRequest("GET", "/foo", nil)}
+	var repo1, repo2 *testRepo
+	assert.Nil(t, c.Resolve(&repo1))
+	assert.Nil(t, c.Resolve(&repo2))
+	assert.True(t, repo1 == repo2)
+	assert.True(t, repo1.db == db1)
+	assert.Equal(t, "/foo", repo1.path)
+
+	c = &Context{app: app, Request: httptest.NewRequest("GET", "/bar", nil)}
+	assert.Nil(t, c.Resolve(&repo2))
+	assert.False(t, repo1 == repo2)
+
+	app.Provide(func() (*testDB, error) {
+		return nil, errors.New("connect failed")
+	})
+	assert.Error(t, app.Resolve(&db1))
+
+	assert.Panics(t, func() { app.Provide(1) })
+	assert.Panics(t, func() { app.Provide(func() {}) })
+	assert.Panics(t, func() { app.Provide(func(c *Context) *testDB { return nil }) })
+}
+
+func TestContainerCycle(t *testing.T) {
+	app := newTestApplication()
+	app.Provide(func(repo *testRepo) *testDB { return &testDB{} })
+	app.Provide(func(db *testDB) *testRepo { return &testRepo{db: db} })
+	var db *testDB
+	assert.Error(t, app.Resolve(&db))
+
+	app.RegisterDynamicRouter(&injectController{}, "", []string{"GET"})
+	err := app.CheckProviders()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "循环依赖")
+	}
+
+	// Singleton不能依赖PerRequest
+	app.Provide(func() *testDB { return &testDB{} })
+	app.Provide(func(c *Context) *testRepo { return &testRepo{} }, PerRequest)
+	app.Provide(func(repo *testRepo) testCache { return mapCache{} })
+	assert.Nil(t, app.CheckProviders())
+	assert.Error(t, app.checkProvided(reflect.TypeOf((*testCache)(nil)).Elem(), false, nil))
+}
+
+type injectController struct {
+	*Controller
+	DB   *testDB   `inject:""`
+	Repo *testRepo `inject:""`
+	dsn  string
+}
+
+func (controller *injectController) Init() {
+	controller.dsn = controller.DB.dsn
+}
+
+func (controller *injectController) ShowAction(c *Context) {
+	c.String(http.StatusOK, controller.dsn+" "+controller.Repo.path)
+}
+
+type badInjectController struct {
+	*Controller
+	db *testDB `inject:""`
+}
+
+func (controller *badInjectController) ShowAction(c *Context) {}
+
+func TestControllerInject(t *testing.T) {
+	app := newTestApplication()
+	app.RegisterDynamicRouter(&injectController{}, "", []string{"GET"})
+	// 依赖可以在注册controller之后注册，启动之前检查
+	err := app.CheckProviders()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "field:DB")
+		assert.Contains(t, err.Error(), "field:Repo")
+	}
+	app.Provide(func() *testDB { return &testDB{dsn: "mysql"} })
+	app.Provide(func(c *Context, db *testDB) *testRepo {
+		return &testRepo{db: db, path: c.Request.URL.Path}
+	}, PerRequest)
+	assert.Nil(t, app.CheckProviders())
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/github.com/panda-win/gwf/inject/show", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "mysql /github.com/panda-win/gwf/inject/show", w.Body.String())
+
+	assert.Panics(t, func() {
+		app.RegisterDynamicRouter(&badInjectController{}, "", []string{"GET"})
+	})
+}
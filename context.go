@@ -1,34 +1,54 @@
 package gwf
 
 import (
+	"context"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math"
 	"mime"
 	"mime/multipart"
 	"net/http"
 	"net/url"
+	"reflect"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-playground/form"
+	"github.com/go-yaml/yaml"
 )
 
 // Context 是对某次请求上下文的抽象
 type Context struct {
 	app     *Application
 	Request *http.Request
-	Writer  *responseWriter
+	Writer  ResponseWriter
+
+	// writermem Writer包装的responseWriter，writers按底层ResponseWriter支持的可选接口缓存包装类型，复用Context时不需要重新分配
+	writermem responseWriter
+	writers   [supportHijack | supportPush | supportCloseNotify + 1]ResponseWriter
 
 	//url参数列表
 	URLParameters url.Values
 
-	//url和POST/PUT/PATCH参数列表，url参数会被POST/PUT/PATCH的同名参数覆盖
+	//url和POST/PUT/PATCH/DELETE参数列表，url参数会被POST/PUT/PATCH/DELETE的同名参数覆盖
+	//调用ParseForm或者第一次读取参数之后才有值
 	URLFormParameters url.Values
 
-	//POST/PUT/PATCH参数列表
+	//POST/PUT/PATCH/DELETE参数列表，调用ParseForm或者第一次读取参数之后才有值
 	FormParameters url.Values
 
+	//路径参数列表，由路由中的:name和*name匹配得到
+	Params Params
+
+	//host参数列表，由路由组绑定的host中的*和:name匹配得到
+	HostParams Params
+
 	// HandlersChain的索引，用于控制handlers链执行流程
 	index int8
 	// 当前请求需要执行的所有handlers集合
@@ -38,68 +58,216 @@ type Context struct {
 	Keys map[string]interface{}
 	// 内部错误
 	errInternal *Error
+	// PerRequest的依赖，请求唯一
+	scoped map[reflect.Type]reflect.Value
+
+	// 请求body是否已经解析，以及解析的错误
+	formParsed bool
+	formErr    error
 }
 
 const abortIndex int8 = math.MaxInt8 / 2
 
 var decoder *form.Decoder = form.NewDecoder()
 
+// pathDecoder、headerDecoder、cookieDecoder 分别使用path、header、cookie tag绑定路径参数、请求头和cookie
+var pathDecoder = newTagDecoder("path")
+var headerDecoder = newTagDecoder("header")
+var cookieDecoder = newTagDecoder("cookie")
+
+func newTagDecoder(tagName string) *form.Decoder {
+	d := form.NewDecoder()
+	d.SetTagName(tagName)
+	return d
+}
+
 func newCtx(app *Application, r *http.Request) *Context {
-	c := &Context{
-		app:     app,
-		Request: r,
-		index:   -1,
-	}
+	c := &Context{app: app}
+	c.reset(r)
+	return c
+}
 
+// reset 重置为请求r的初始状态，用于复用Context，不会修改app和Writer
+func (c *Context) reset(r *http.Request) {
+	c.Request = r
 	c.URLParameters = r.URL.Query()
+	c.URLFormParameters = nil
+	c.FormParameters = nil
+	c.Params = nil
+	c.HostParams = nil
+	c.index = -1
+	c.handlers = nil
+	c.Keys = nil
+	c.errInternal = nil
+	c.scoped = nil
+	c.formParsed = false
+	c.formErr = nil
+}
+
+// resetWriter 将Writer重置为w，Writer只在w支持时实现http.Hijacker、http.Pusher以及http.CloseNotifier
+func (c *Context) resetWriter(w http.ResponseWriter) {
+	c.writermem.reset(w)
+	capabilities := writerCapabilities(w)
+	if c.writers[capabilities] == nil {
+		c.writers[capabilities] = wrapResponseWriter(&c.writermem, capabilities)
+	}
+	c.Writer = c.writers[capabilities]
+}
+
+// Copy 返回可以在其他goroutine中安全使用的Context副本
+// 请求结束后Context会被其他请求复用，handler中启动的goroutine必须使用副本
+//  cp := c.Copy()
+//  go func() {
+//  	sendMail(cp, cp.ParamString("email"))
+//  }()
+// 副本不能写入响应，Writer为nil；副本的Done在请求结束后同样会被关闭
+// 请求结束后无法再读取body，所以Copy会先调用ParseForm，副本读取参数时不会再解析body
+func (c *Context) Copy() *Context {
+	c.ParseForm()
+	cp := &Context{
+		app:               c.app,
+		Request:           c.Request,
+		URLParameters:     c.URLParameters,
+		URLFormParameters: c.URLFormParameters,
+		FormParameters:    c.FormParameters,
+		index:             abortIndex,
+		errInternal:       c.errInternal,
+		formParsed:        c.formParsed,
+		formErr:           c.formErr,
+	}
+	cp.Params = append(Params(nil), c.Params...)
+	cp.HostParams = append(Params(nil), c.HostParams...)
+	if c.Keys != nil {
+		cp.Keys = make(map[string]interface{}, len(c.Keys))
+		for k, v := range c.Keys {
+			cp.Keys[k] = v
+		}
+	}
+	if c.scoped != nil {
+		cp.scoped = make(map[reflect.Type]reflect.Value, len(c.scoped))
+		for k, v := range c.scoped {
+			cp.scoped[k] = v
+		}
+	}
+	return cp
+}
 
-	if r.Method == http.MethodPost {
-		v := r.Header.Get("Content-Type")
-
-		var parseMultipart bool = false
+// ParseForm 解析POST/PUT/PATCH/DELETE请求body中的参数，只会解析一次
+// 支持application/x-www-form-urlencoded和multipart/form-data，解析失败时返回*ParseError
+// 请求body在第一次读取参数时才会解析，ParamXXX、FormXXX等函数解析失败时会panic(*ParseError)，
+// ServeHTTP会根据ParseError.Code响应400或者413，需要自己处理错误时请先调用ParseForm
+func (c *Context) ParseForm() error {
+	if !c.formParsed {
+		c.formParsed = true
+		c.formErr = c.parseForm()
+	}
+	return c.formErr
+}
 
-		if v != "" {
-			d, _, err := mime.ParseMediaType(v)
-			if err == nil && d == "multipart/form-data" {
-				parseMultipart = true
-			}
-		}
+func (c *Context) parseForm() error {
+	r := c.Request
+	c.URLFormParameters = c.URLParameters
+	c.FormParameters = make(url.Values)
+	switch r.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return nil
+	}
 
-		if parseMultipart {
-			err := r.ParseMultipartForm(app.maxMultipartMemory)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	var err error
+	switch {
+	case mediaType == "multipart/form-data":
+		err = r.ParseMultipartForm(c.maxMultipartMemory())
+	case mediaType == "application/x-www-form-urlencoded" && r.Method == http.MethodDelete:
+		// net/http只解析POST/PUT/PATCH的body
+		err = parseDeleteForm(r)
+	default:
+		err = r.ParseForm()
+	}
+	if err != nil {
+		return newParseError(err)
+	}
 
-			if err != nil {
-				panic(fmt.Sprintf("ParseMultipartForm 失败, error:%s", err))
-			}
+	c.URLFormParameters = r.Form
+	c.FormParameters = r.PostForm
+	return nil
+}
 
-		} else {
-			err := r.ParseForm()
-			if err != nil {
-				//如果解析参数失败，后续流程无法进行，快速失败，日志中将会有记录
-				panic(fmt.Sprintf("解析url参数和POST/PUT/PATCH上传参数失败, error:%s", err))
-			}
+// parseDeleteForm 解析DELETE请求的application/x-www-form-urlencoded参数，body参数优先于url参数
+func parseDeleteForm(r *http.Request) error {
+	var body url.Values
+	if r.Body != nil {
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return err
 		}
+		if body, err = url.ParseQuery(string(b)); err != nil {
+			return err
+		}
+	}
+	query, err := url.ParseQuery(r.URL.RawQuery)
+	if err != nil {
+		return err
+	}
+	r.PostForm = make(url.Values)
+	r.Form = make(url.Values)
+	for k, v := range body {
+		r.PostForm[k] = append(r.PostForm[k], v...)
+		r.Form[k] = append(r.Form[k], v...)
+	}
+	for k, v := range query {
+		r.Form[k] = append(r.Form[k], v...)
+	}
+	return nil
+}
 
-		c.URLFormParameters = c.Request.Form
-
-	} else {
-		c.URLFormParameters = c.URLParameters
+func (c *Context) maxMultipartMemory() int64 {
+	if c.app == nil {
+		return defaultMultipartMemory
 	}
+	return c.app.maxMultipartMemory
+}
 
-	c.FormParameters = c.Request.PostForm
+// urlFormValues 返回url参数和body参数，解析body失败时panic(*ParseError)
+func (c *Context) urlFormValues() url.Values {
+	if err := c.ParseForm(); err != nil {
+		panic(err)
+	}
+	return c.URLFormParameters
+}
 
-	return c
+// formValues 返回body参数，解析body失败时panic(*ParseError)
+func (c *Context) formValues() url.Values {
+	if err := c.ParseForm(); err != nil {
+		panic(err)
+	}
+	return c.FormParameters
 }
 
 // Next 循环执行hanlers链中的handler
+// 客户端断开连接或者路由超时后，剩余的handlers不再执行
 func (c *Context) Next() {
 	c.index++
 	for c.index < int8(len(c.handlers)) {
+		if err := c.Err(); err != nil {
+			c.abortWithContextErr(err)
+			return
+		}
 		c.handlers[c.index](c)
 		c.index++
 	}
 }
 
+// abortWithContextErr 请求被取消时终止handlers，超时并且没有写入响应时返回503
+// 客户端断开连接时不需要再写入响应
+func (c *Context) abortWithContextErr(err error) {
+	c.Abort()
+	if errors.Is(err, context.DeadlineExceeded) && c.Writer != nil && !c.Writer.Written() {
+		c.AbortWithStatus(http.StatusServiceUnavailable)
+	}
+}
+
 // Status 写入响应code
 func (c *Context) Status(code int) {
 	c.Writer.WriteHeader(code)
@@ -150,6 +318,53 @@ func (c *Context) Get(key string) (value interface{}, exists bool) {
 	return
 }
 
+/**********context.Context接口 begin**********/
+// Context实现了context.Context接口，可以直接传给数据库、RPC等需要context.Context的调用
+// Deadline、Done、Err使用c.Request.Context()，客户端断开连接或者路由超时时Done被关闭
+//  rows, err := db.QueryContext(c, "SELECT name FROM user WHERE id = ?", id)
+// 请求结束后Context会被其他请求复用，这些方法读取的Request和Keys也会随之改变，并且没有加锁，
+// 所以在handler返回之后仍然会使用context.Context的代码(比如启动的goroutine、errgroup以及没有关闭的sql.Rows)必须使用c.Copy()
+//  cp := c.Copy()
+//  go watch(cp)
+func (c *Context) Deadline() (deadline time.Time, ok bool) {
+	if c.Request == nil {
+		return
+	}
+	return c.Request.Context().Deadline()
+}
+
+// Done 返回的channel在客户端断开连接或者路由超时时被关闭
+// 只能在handler返回之前使用，需要在handler返回之后等待时请使用c.Copy().Done()
+func (c *Context) Done() <-chan struct{} {
+	if c.Request == nil {
+		return nil
+	}
+	return c.Request.Context().Done()
+}
+
+func (c *Context) Err() error {
+	if c.Request == nil {
+		return nil
+	}
+	return c.Request.Context().Err()
+}
+
+// Value 先从c.Request.Context()中取值，没有时key为string则从Keys中取值
+// 读取Keys时没有加锁，只能在handler返回之前使用，需要在handler返回之后或者其他goroutine中取值时请使用c.Copy()
+func (c *Context) Value(key interface{}) interface{} {
+	if c.Request != nil {
+		if v := c.Request.Context().Value(key); v != nil {
+			return v
+		}
+	}
+	if k, ok := key.(string); ok {
+		return c.Keys[k]
+	}
+	return nil
+}
+
+/**********context.Context接口 end**********/
+
 /**********请求参数相关方法 begin**********/
 func intDefault(v string, defaultV int) int {
 	if v == "" {
@@ -295,17 +510,17 @@ func boolDefault(v string, defaultV bool) bool {
 	return b
 }
 
-// ParamXXX和ParamXXXDefault函数可以取得url参数和POST、PUT、PATCH上传的参数
-// 如果某个参数在url参数和POST、PUT、PATCH上传参数中都有，将取得POST、PUT、PATCH参数
+// ParamXXX和ParamXXXDefault函数可以取得url参数和POST、PUT、PATCH、DELETE上传的参数
+// 如果某个参数在url参数和POST、PUT、PATCH、DELETE上传参数中都有，将取得POST、PUT、PATCH、DELETE参数
 // 如果一定要取得url参数，请使用QueryXXX和QueryXXXDefault方法
 // 如果没有为key的参数名，或者值为空字符串，ParamXXX返回XXX类型的默认零值
 // ParamXXXDefault返回传入的defaultV
 func (c *Context) ParamString(key string) string {
-	return c.URLFormParameters.Get(key)
+	return c.urlFormValues().Get(key)
 }
 
 func (c *Context) ParamStringDefault(key string, defaultV string) string {
-	v := c.URLFormParameters.Get(key)
+	v := c.urlFormValues().Get(key)
 	if v == "" {
 		return defaultV
 	}
@@ -314,7 +529,7 @@ func (c *Context) ParamStringDefault(key string, defaultV string) string {
 }
 
 func (c *Context) ParamStringSlice(key string) []string {
-	return c.URLFormParameters[key]
+	return c.urlFormValues()[key]
 }
 
 func (c *Context) ParamInt(key string) int {
@@ -594,11 +809,11 @@ func (c *Context) QueryBoolDefault(key string, defaultV bool) bool {
 }
 
 func (c *Context) FormString(key string) string {
-	return c.FormParameters.Get(key)
+	return c.formValues().Get(key)
 }
 
 func (c *Context) FormStringDefault(key string, defaultV string) string {
-	v := c.FormParameters.Get(key)
+	v := c.formValues().Get(key)
 	if v == "" {
 		return defaultV
 	}
@@ -606,7 +821,7 @@ func (c *Context) FormStringDefault(key string, defaultV string) string {
 }
 
 func (c *Context) FormStringSlice(key string) []string {
-	return c.FormParameters[key]
+	return c.formValues()[key]
 }
 
 func (c *Context) FormInt(key string) int {
@@ -739,27 +954,188 @@ func (c *Context) FormBoolDefault(key string, defaultV bool) bool {
 	return boolDefault(v, defaultV)
 }
 
-// MultipartFormParameters返回form的enctype="multipart/form-data"的POST/PUT/PATCH参数
+// PathParamXXX和PathParamXXXDefault函数可以取得路径参数
+// 比如路由/user/:id匹配/user/123时，c.PathParam("id")返回"123"，c.PathParamInt("id")返回123
+// 如果没有为key的参数名，或者值为空字符串，PathParamXXX返回XXX类型的默认零值
+// PathParamXXXDefault返回传入的defaultV
+func (c *Context) PathParam(key string) string {
+	return c.Params.ByName(key)
+}
+
+func (c *Context) PathParamDefault(key string, defaultV string) string {
+	v := c.Params.ByName(key)
+	if v == "" {
+		return defaultV
+	}
+	return v
+}
+
+func (c *Context) PathParamInt(key string) int {
+	v := c.PathParam(key)
+	return intDefault(v, 0)
+}
+
+func (c *Context) PathParamIntDefault(key string, defaultV int) int {
+	v := c.PathParam(key)
+	return intDefault(v, defaultV)
+}
+
+func (c *Context) PathParamInt8(key string) int8 {
+	v := c.PathParam(key)
+	return int8Default(v, 0)
+}
+
+func (c *Context) PathParamInt8Default(key string, defaultV int8) int8 {
+	v := c.PathParam(key)
+	return int8Default(v, defaultV)
+}
+
+func (c *Context) PathParamInt16(key string) int16 {
+	v := c.PathParam(key)
+	return int16Default(v, 0)
+}
+
+func (c *Context) PathParamInt16Default(key string, defaultV int16) int16 {
+	v := c.PathParam(key)
+	return int16Default(v, defaultV)
+}
+
+func (c *Context) PathParamInt32(key string) int32 {
+	v := c.PathParam(key)
+	return int32Default(v, 0)
+}
+
+func (c *Context) PathParamInt32Default(key string, defaultV int32) int32 {
+	v := c.PathParam(key)
+	return int32Default(v, defaultV)
+}
+
+func (c *Context) PathParamInt64(key string) int64 {
+	v := c.PathParam(key)
+	return int64Default(v, 0)
+}
+
+func (c *Context) PathParamInt64Default(key string, defaultV int64) int64 {
+	v := c.PathParam(key)
+	return int64Default(v, defaultV)
+}
+
+func (c *Context) PathParamUint(key string) uint {
+	v := c.PathParam(key)
+	return uintDefault(v, 0)
+}
+
+func (c *Context) PathParamUintDefault(key string, defaultV uint) uint {
+	v := c.PathParam(key)
+	return uintDefault(v, defaultV)
+}
+
+func (c *Context) PathParamUint8(key string) uint8 {
+	v := c.PathParam(key)
+	return uint8Default(v, 0)
+}
+
+func (c *Context) PathParamUint8Default(key string, defaultV uint8) uint8 {
+	v := c.PathParam(key)
+	return uint8Default(v, defaultV)
+}
+
+func (c *Context) PathParamUint16(key string) uint16 {
+	v := c.PathParam(key)
+	return uint16Default(v, 0)
+}
+
+func (c *Context) PathParamUint16Default(key string, defaultV uint16) uint16 {
+	v := c.PathParam(key)
+	return uint16Default(v, defaultV)
+}
+
+func (c *Context) PathParamUint32(key string) uint32 {
+	v := c.PathParam(key)
+	return uint32Default(v, 0)
+}
+
+func (c *Context) PathParamUint32Default(key string, defaultV uint32) uint32 {
+	v := c.PathParam(key)
+	return uint32Default(v, defaultV)
+}
+
+func (c *Context) PathParamUint64(key string) uint64 {
+	v := c.PathParam(key)
+	return uint64Default(v, 0)
+}
+
+func (c *Context) PathParamUint64Default(key string, defaultV uint64) uint64 {
+	v := c.PathParam(key)
+	return uint64Default(v, defaultV)
+}
+
+func (c *Context) PathParamFloat32(key string) float32 {
+	v := c.PathParam(key)
+	return float32Default(v, 0.0)
+}
+
+func (c *Context) PathParamFloat32Default(key string, defaultV float32) float32 {
+	v := c.PathParam(key)
+	return float32Default(v, defaultV)
+}
+
+func (c *Context) PathParamFloat64(key string) float64 {
+	v := c.PathParam(key)
+	return float64Default(v, 0.0)
+}
+
+func (c *Context) PathParamFloat64Default(key string, defaultV float64) float64 {
+	v := c.PathParam(key)
+	return float64Default(v, defaultV)
+}
+
+func (c *Context) PathParamBool(key string) bool {
+	v := c.PathParam(key)
+	return boolDefault(v, false)
+}
+
+func (c *Context) PathParamBoolDefault(key string, defaultV bool) bool {
+	v := c.PathParam(key)
+	return boolDefault(v, defaultV)
+}
+
+// HostParam 返回路由组绑定的host中*或者:name匹配到的label
+// 比如host为*.tenant.example.com时，c.HostParam("*")返回*匹配到的label
+func (c *Context) HostParam(key string) string {
+	return c.HostParams.ByName(key)
+}
+
+// MultipartFormParameters返回form的enctype="multipart/form-data"的POST/PUT/PATCH/DELETE参数
 func (c *Context) MultipartFormParameters() (url.Values, error) {
-	if c.Request.MultipartForm == nil {
-		if err := c.Request.ParseMultipartForm(c.app.maxMultipartMemory); err != nil {
-			return nil, err
-		}
+	if err := c.parseMultipartForm(); err != nil {
+		return nil, err
 	}
 
 	return c.Request.MultipartForm.Value, nil
 }
 
 func (c *Context) FormFile(name string) (*multipart.FileHeader, error) {
-	if c.Request.MultipartForm == nil {
-		if err := c.Request.ParseMultipartForm(c.app.maxMultipartMemory); err != nil {
-			return nil, err
-		}
+	if err := c.parseMultipartForm(); err != nil {
+		return nil, err
 	}
 	_, fh, err := c.Request.FormFile(name)
 	return fh, err
 }
 
+// parseMultipartForm 解析multipart/form-data的body，解析失败时返回*ParseError
+func (c *Context) parseMultipartForm() error {
+	if err := c.ParseForm(); err != nil {
+		return err
+	}
+	if c.Request.MultipartForm == nil {
+		if err := c.Request.ParseMultipartForm(c.maxMultipartMemory()); err != nil {
+			return newParseError(err)
+		}
+	}
+	return nil
+}
+
 func (c *Context) GetReqeustBody() ([]byte, error) {
 
 	return ioutil.ReadAll(c.Request.Body)
@@ -768,15 +1144,281 @@ func (c *Context) GetReqeustBody() ([]byte, error) {
 /**********请求参数相关函数 end**********/
 
 /**********参数绑定到struct相关函数 begin**********/
-// Bind 用于参数绑定，dst必须是struct的指针类型
+// Bind 用于参数绑定，dst必须是struct的指针类型，绑定失败时返回*BindError
+// 绑定之后会使用validate tag校验dst，校验失败时返回ValidationErrors，Bind系列函数都是如此
 func (c *Context) Bind(dst interface{}, src url.Values) error {
+	if err := c.decodeValues(dst, src); err != nil {
+		return err
+	}
+	return c.Validate(dst)
+}
+
+// decodeValues 将src绑定到dst，不校验
+func (c *Context) decodeValues(dst interface{}, src url.Values) error {
 	err := decoder.Decode(dst, src)
 	if err != nil {
+		return &BindError{Source: "form", Err: err}
+	}
+	return nil
+}
+
+// BindPath 将路径参数绑定到dst中有path tag的字段
+//  // GET /user/:id
+//  type ShowUserReq struct {
+//  	ID int64 `path:"id"`
+//  }
+func (c *Context) BindPath(dst interface{}) error {
+	if err := c.decodePath(dst); err != nil {
+		return err
+	}
+	return c.Validate(dst)
+}
+
+// BindHeader 将请求头绑定到dst中有header tag的字段，tag使用规范格式(X-Token)或者小写(x-token)
+//  type AuthReq struct {
+//  	Token string `header:"X-Token"`
+//  }
+func (c *Context) BindHeader(dst interface{}) error {
+	if err := c.decodeHeader(dst); err != nil {
+		return err
+	}
+	return c.Validate(dst)
+}
+
+// BindCookie 将cookie绑定到dst中有cookie tag的字段
+//  type SessionReq struct {
+//  	SessionID string `cookie:"sid"`
+//  }
+func (c *Context) BindCookie(dst interface{}) error {
+	if err := c.decodeCookie(dst); err != nil {
+		return err
+	}
+	return c.Validate(dst)
+}
+
+// BindRequest 根据字段的tag从不同的来源绑定参数，所有来源都绑定之后再校验:
+//  path    路径参数
+//  header  请求头
+//  cookie  cookie
+//  form    url参数和POST/PUT/PATCH参数
+//  json/xml/yaml  请求body，根据Content-Type选择，body可以为空
+// 有path、header、cookie tag的字段只从对应的来源绑定，url参数和body中的同名key不会修改这些字段
+// 例如:
+//  // PUT /user/:id
+//  type UpdateUserReq struct {
+//  	ID    int64  `path:"id"`
+//  	Token string `header:"X-Token" validate:"required"`
+//  	SID   string `cookie:"sid"`
+//  	Name  string `form:"name" json:"name" validate:"required"`
+//  }
+func (c *Context) BindRequest(dst interface{}) error {
+	if err := c.decodeRequest(dst); err != nil {
+		return err
+	}
+	return c.Validate(dst)
+}
+
+// decodeRequest 从所有来源绑定参数，不校验
+// 客户端可以控制url参数和body，其中与字段名称相同的key也会被绑定，
+// 所以有path、header、cookie tag的字段只从对应的来源绑定，url参数和body对这些字段的修改会被还原
+func (c *Context) decodeRequest(dst interface{}) error {
+	restore := saveSourceFields(dst)
+	if err := c.ParseForm(); err != nil {
 		return err
 	}
+	if err := c.decodeValues(dst, c.URLFormParameters); err != nil {
+		return err
+	}
+	if decode := c.bodyDecoder(); decode != nil {
+		if err := decode(dst); err != nil && !errors.Is(err, ErrEmptyBody) {
+			return err
+		}
+	}
+	restore()
+	if err := c.decodeCookie(dst); err != nil {
+		return err
+	}
+	if err := c.decodeHeader(dst); err != nil {
+		return err
+	}
+	return c.decodePath(dst)
+}
+
+// sourceTagNames 只能从对应来源绑定的字段的tag
+var sourceTagNames = []string{"path", "header", "cookie"}
+
+// sourceFieldsCache 缓存sourceFieldIndexes的结果
+var sourceFieldsCache sync.Map
+
+// sourceFieldIndexes 返回t(struct或struct的指针)中有path、header或者cookie tag的字段的index，包括嵌入的struct中的字段
+func sourceFieldIndexes(t reflect.Type) [][]int {
+	if indexes, ok := sourceFieldsCache.Load(t); ok {
+		return indexes.([][]int)
+	}
+
+	var indexes [][]int
+	var collect func(t reflect.Type, prefix []int)
+	collect = func(t reflect.Type, prefix []int) {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return
+		}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			index := append(append([]int(nil), prefix...), i)
+			tagged := false
+			for _, tagName := range sourceTagNames {
+				if name := strings.SplitN(field.Tag.Get(tagName), ",", 2)[0]; name != "" && name != "-" {
+					tagged = true
+					break
+				}
+			}
+			if tagged {
+				indexes = append(indexes, index)
+			} else if field.Anonymous {
+				collect(field.Type, index)
+			}
+		}
+	}
+	collect(t, nil)
+
+	sourceFieldsCache.Store(t, indexes)
+	return indexes
+}
+
+// saveSourceFields 保存dst中有path、header或者cookie tag的字段的值，返回的函数将这些字段还原为保存的值
+func saveSourceFields(dst interface{}) (restore func()) {
+	v := reflect.ValueOf(dst)
+	indexes := sourceFieldIndexes(v.Type())
+	if len(indexes) == 0 || v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return func() {}
+	}
+	saved := make([]reflect.Value, len(indexes))
+	for i, index := range indexes {
+		if field, ok := fieldByIndex(v.Elem(), index); ok {
+			saved[i] = reflect.New(field.Type()).Elem()
+			saved[i].Set(field)
+		}
+	}
+	return func() {
+		for i, index := range indexes {
+			field, ok := fieldByIndex(v.Elem(), index)
+			if !ok {
+				continue
+			}
+			if saved[i].IsValid() {
+				field.Set(saved[i])
+			} else {
+				// 嵌入的struct指针是在绑定url参数或者body时创建的
+				field.Set(reflect.Zero(field.Type()))
+			}
+		}
+	}
+}
+
+// fieldByIndex 与reflect.Value.FieldByIndex相同，嵌入的struct指针为nil时返回false，不会panic
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 {
+			for v.Kind() == reflect.Ptr {
+				if v.IsNil() {
+					return reflect.Value{}, false
+				}
+				v = v.Elem()
+			}
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func (c *Context) decodePath(dst interface{}) error {
+	values := make(url.Values, len(c.Params))
+	for _, p := range c.Params {
+		values.Add(p.Key, p.Value)
+	}
+	return c.decodeTagged("path", pathDecoder, dst, values)
+}
+
+func (c *Context) decodeHeader(dst interface{}) error {
+	values := make(url.Values, len(c.Request.Header)*2)
+	for key, v := range c.Request.Header {
+		values[key] = v
+		values[strings.ToLower(key)] = v
+	}
+	return c.decodeTagged("header", headerDecoder, dst, values)
+}
+
+func (c *Context) decodeCookie(dst interface{}) error {
+	values := make(url.Values)
+	for _, cookie := range c.Request.Cookies() {
+		values.Add(cookie.Name, cookie.Value)
+	}
+	return c.decodeTagged("cookie", cookieDecoder, dst, values)
+}
+
+// decodeTagged 只绑定dst中有tagName tag的字段，没有tag的字段不会被同名的请求头等覆盖
+func (c *Context) decodeTagged(tagName string, d *form.Decoder, dst interface{}, src url.Values) error {
+	names := taggedFieldNames(reflect.TypeOf(dst), tagName)
+	if len(names) == 0 {
+		return nil
+	}
+	values := make(url.Values, len(names))
+	for name := range names {
+		if v, ok := src[name]; ok {
+			values[name] = v
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	if err := d.Decode(dst, values); err != nil {
+		return &BindError{Source: tagName, Err: err}
+	}
 	return nil
 }
 
+type taggedFieldsKey struct {
+	t       reflect.Type
+	tagName string
+}
+
+// taggedFieldsCache 缓存taggedFieldNames的结果
+var taggedFieldsCache sync.Map
+
+// taggedFieldNames 返回t(struct或struct的指针)中tagName tag的值，包括嵌入的struct中的字段
+func taggedFieldNames(t reflect.Type, tagName string) map[string]bool {
+	key := taggedFieldsKey{t: t, tagName: tagName}
+	if names, ok := taggedFieldsCache.Load(key); ok {
+		return names.(map[string]bool)
+	}
+
+	names := make(map[string]bool)
+	var collect func(t reflect.Type)
+	collect = func(t reflect.Type) {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return
+		}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if name := strings.SplitN(field.Tag.Get(tagName), ",", 2)[0]; name != "" && name != "-" {
+				names[name] = true
+			} else if field.Anonymous {
+				collect(field.Type)
+			}
+		}
+	}
+	collect(t)
+
+	taggedFieldsCache.Store(key, names)
+	return names
+}
+
 // BindQuery 将url参数绑定到dst
 func (c *Context) BindQuery(dst interface{}) error {
 	err := c.Bind(dst, c.URLParameters)
@@ -786,8 +1428,11 @@ func (c *Context) BindQuery(dst interface{}) error {
 	return nil
 }
 
-// BindParam 将url参数和POST/PUT/PATCH参数绑定到dst
+// BindParam 将url参数和POST/PUT/PATCH/DELETE参数绑定到dst
 func (c *Context) BindParam(dst interface{}) error {
+	if err := c.ParseForm(); err != nil {
+		return err
+	}
 	err := c.Bind(dst, c.URLFormParameters)
 	if err != nil {
 		return err
@@ -795,8 +1440,11 @@ func (c *Context) BindParam(dst interface{}) error {
 	return nil
 }
 
-// BindForm 将POST/PUT/PATCH参数绑定到dst
+// BindForm 将POST/PUT/PATCH/DELETE参数绑定到dst
 func (c *Context) BindForm(dst interface{}) error {
+	if err := c.ParseForm(); err != nil {
+		return err
+	}
 	err := c.Bind(dst, c.FormParameters)
 	if err != nil {
 		return err
@@ -819,6 +1467,103 @@ func (c *Context) BindMultipartForm(dst interface{}) error {
 	return nil
 }
 
+// BindJSON 将json格式的请求body绑定到dst，绑定失败时返回*BindError
+func (c *Context) BindJSON(dst interface{}) error {
+	if err := c.decodeJSON(dst); err != nil {
+		return err
+	}
+	return c.Validate(dst)
+}
+
+// BindXML 将xml格式的请求body绑定到dst，绑定失败时返回*BindError
+func (c *Context) BindXML(dst interface{}) error {
+	if err := c.decodeXML(dst); err != nil {
+		return err
+	}
+	return c.Validate(dst)
+}
+
+// BindYAML 将yaml格式的请求body绑定到dst，绑定失败时返回*BindError
+func (c *Context) BindYAML(dst interface{}) error {
+	if err := c.decodeYAML(dst); err != nil {
+		return err
+	}
+	return c.Validate(dst)
+}
+
+func (c *Context) decodeJSON(dst interface{}) error {
+	return c.decodeBody("json", func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(dst)
+	})
+}
+
+func (c *Context) decodeXML(dst interface{}) error {
+	return c.decodeBody("xml", func(r io.Reader) error {
+		return xml.NewDecoder(r).Decode(dst)
+	})
+}
+
+func (c *Context) decodeYAML(dst interface{}) error {
+	return c.decodeBody("yaml", func(r io.Reader) error {
+		return yaml.NewDecoder(r).Decode(dst)
+	})
+}
+
+// ShouldBind 根据请求的Content-Type选择绑定方式:
+//  application/json                      BindJSON
+//  application/xml、text/xml             BindXML
+//  application/x-yaml、application/yaml  BindYAML
+//  multipart/form-data                   BindMultipartForm
+//  其他                                  BindParam
+func (c *Context) ShouldBind(dst interface{}) error {
+	if decode := c.bodyDecoder(); decode != nil {
+		if err := decode(dst); err != nil {
+			return err
+		}
+		return c.Validate(dst)
+	}
+	mediaType, _, _ := mime.ParseMediaType(c.Request.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		return c.BindMultipartForm(dst)
+	}
+	return c.BindParam(dst)
+}
+
+// bodyDecoder 根据Content-Type返回解析请求body的方法，body不是json、xml、yaml时返回nil
+func (c *Context) bodyDecoder() func(dst interface{}) error {
+	mediaType, _, err := mime.ParseMediaType(c.Request.Header.Get("Content-Type"))
+	if err != nil {
+		return nil
+	}
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return c.decodeJSON
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		return c.decodeXML
+	case mediaType == "application/x-yaml" || mediaType == "application/yaml" ||
+		mediaType == "text/yaml" || mediaType == "text/x-yaml":
+		return c.decodeYAML
+	}
+	return nil
+}
+
+// decodeBody 使用decode解析请求body，body为空时返回的*BindError中Err是ErrEmptyBody
+func (c *Context) decodeBody(source string, decode func(r io.Reader) error) error {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return &BindError{Source: source, Err: ErrEmptyBody}
+	}
+	if err := decode(c.Request.Body); err != nil {
+		if errors.Is(err, ErrBodyTooLarge) {
+			return newParseError(err)
+		}
+		if err == io.EOF {
+			err = ErrEmptyBody
+		}
+		return &BindError{Source: source, Err: err}
+	}
+	return nil
+}
+
 /**********参数绑定到struct相关函数 end**********/
 
 /**********输出相关函数 begin**********/
@@ -866,12 +1611,18 @@ func (c *Context) RenderAdminDefaultLayout(tmplName string, data map[string]inte
 // Json 将data输出，data一般是一个struct
 // 调用方需要自己使用return控制程序流程
 func (c *Context) Json(code int, data interface{}) {
-	b, err := json.Marshal(data)
-	if err != nil {
-		panic(fmt.Sprintf("错误 err:%s", err))
-	}
-	c.Writer.Header().Add("Content-Type", "application/json; charset=UTF-8")
-	c.Bytes(code, b)
+	c.RenderWith(code, JsonRenderer{}, data)
+}
+
+// Xml 将data输出为xml
+// 调用方需要自己使用return控制程序流程
+func (c *Context) Xml(code int, data interface{}) {
+	c.RenderWith(code, XmlRenderer{}, data)
+}
+
+// URLFor 生成命名路由的url，详见Application.URLFor
+func (c *Context) URLFor(name string, params map[string]interface{}) (string, error) {
+	return c.app.URLFor(name, params)
 }
 
 func (c *Context) Redirect301(location string) {
@@ -1,11 +1,17 @@
 package gwf
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -323,6 +329,306 @@ func TestBind(t *testing.T) {
 	assert.Equal(t, f3, expectF3)
 }
 
+type person struct {
+	Name string   `json:"name" xml:"name" yaml:"name" form:"name"`
+	Age  int      `json:"age" xml:"age" yaml:"age" form:"age"`
+	Tags []string `json:"tags" xml:"tags" yaml:"tags" form:"tags"`
+}
+
+func TestShouldBind(t *testing.T) {
+	expect := person{Name: "tom", Age: 18, Tags: []string{"a", "b"}}
+	tests := []struct {
+		contentType string
+		body        string
+	}{
+		{"application/json; charset=UTF-8", `{"name":"tom","age":18,"tags":["a","b"]}`},
+		{"application/vnd.api+json", `{"name":"tom","age":18,"tags":["a","b"]}`},
+		{"application/xml", `<person><name>tom</name><age>18</age><tags>a</tags><tags>b</tags></person>`},
+		{"text/xml", `<person><name>tom</name><age>18</age><tags>a</tags><tags>b</tags></person>`},
+		{"application/x-yaml", "name: tom\nage: 18\ntags: [a, b]\n"},
+		{"application/x-www-form-urlencoded", "name=tom&age=18&tags=a&tags=b"},
+	}
+	for _, tt := range tests {
+		r, _ := http.NewRequest("POST", "/person", strings.NewReader(tt.body))
+		r.Header.Set("Content-Type", tt.contentType)
+		c := newCtx(nil, r)
+		p := person{}
+		assert.Nil(t, c.ShouldBind(&p), tt.contentType)
+		assert.Equal(t, expect, p, tt.contentType)
+	}
+}
+
+func TestBindError(t *testing.T) {
+	r, _ := http.NewRequest("POST", "/person", strings.NewReader(`{"name":1}`))
+	r.Header.Set("Content-Type", "application/json")
+	c := newCtx(nil, r)
+	err := c.ShouldBind(&person{})
+	bindErr, ok := err.(*BindError)
+	if assert.True(t, ok) {
+		assert.Equal(t, "json", bindErr.Source)
+	}
+
+	r, _ = http.NewRequest("POST", "/person", strings.NewReader(""))
+	r.Header.Set("Content-Type", "application/xml")
+	c = newCtx(nil, r)
+	err = c.BindXML(&person{})
+	assert.True(t, errors.Is(err, ErrEmptyBody))
+
+	r, _ = http.NewRequest("GET", "/person?age=x", nil)
+	c = newCtx(nil, r)
+	err = c.ShouldBind(&person{})
+	bindErr, ok = err.(*BindError)
+	if assert.True(t, ok) {
+		assert.Equal(t, "form", bindErr.Source)
+	}
+}
+
+type updateUserReq struct {
+	ID      int64    `path:"id"`
+	Token   string   `header:"X-Token" validate:"required"`
+	Trace   string   `header:"x-trace-id"`
+	Langs   []string `header:"Accept-Language"`
+	SID     string   `cookie:"sid"`
+	Name    string   `form:"name" json:"name" validate:"required"`
+	Age     int      `form:"age" json:"age"`
+	Referer string
+}
+
+func TestBindRequest(t *testing.T) {
+	r, _ := http.NewRequest("PUT", "/user/12?age=18", strings.NewReader(`{"name":"tom"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("X-Token", "token")
+	r.Header.Set("X-Trace-Id", "trace")
+	r.Header.Add("Accept-Language", "zh")
+	r.Header.Add("Accept-Language", "en")
+	r.Header.Set("Referer", "http://example.com")
+	r.AddCookie(&http.Cookie{Name: "sid", Value: "s1"})
+	c := newCtx(nil, r)
+	c.Params = Params{{"id", "12"}}
+
+	req := updateUserReq{}
+	assert.Nil(t, c.BindRequest(&req))
+	assert.Equal(t, updateUserReq{
+		ID:    12,
+		Token: "token",
+		Trace: "trace",
+		Langs: []string{"zh", "en"},
+		SID:   "s1",
+		Name:  "tom",
+		Age:   18,
+	}, req)
+
+	// url参数和body不能覆盖路径参数
+	app := newTestApplication()
+	app.PUT("/users/:id", func(c *Context) {
+		req := updateUserReq{}
+		assert.Nil(t, c.BindRequest(&req))
+		c.String(http.StatusOK, strconv.FormatInt(req.ID, 10))
+	})
+	w := httptest.NewRecorder()
+	r, _ = http.NewRequest("PUT", "/users/12?id=99&ID=98", strings.NewReader(`{"id":97,"name":"tom"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("X-Token", "token")
+	app.ServeHTTP(w, r)
+	assert.Equal(t, "12", w.Body.String())
+
+	// header不存在时，url参数和body中与字段名称相同的key不能设置header字段
+	forged := func(r *http.Request) error {
+		c := newCtx(nil, r)
+		c.Params = Params{{"id", "12"}}
+		req := updateUserReq{}
+		err := c.BindRequest(&req)
+		assert.Equal(t, "", req.Token)
+		assert.Equal(t, "", req.SID)
+		assert.Equal(t, int64(12), req.ID)
+		return err
+	}
+	r, _ = http.NewRequest("GET", "/user/12?Token=forged&SID=forged&ID=1&name=tom", nil)
+	_, ok := forged(r).(ValidationErrors)
+	assert.True(t, ok)
+	r, _ = http.NewRequest("PUT", "/user/12", strings.NewReader(`{"Token":"forged","SID":"forged","ID":1,"name":"tom"}`))
+	r.Header.Set("Content-Type", "application/json")
+	_, ok = forged(r).(ValidationErrors)
+	assert.True(t, ok)
+
+	show := struct {
+		ID int64 `path:"id"`
+	}{}
+	assert.Nil(t, c.BindPath(&show))
+	assert.Equal(t, int64(12), show.ID)
+
+	r, _ = http.NewRequest("GET", "/user/abc", nil)
+	c = newCtx(nil, r)
+	c.Params = Params{{"id", "abc"}}
+	err := c.BindPath(&updateUserReq{})
+	bindErr, ok := err.(*BindError)
+	if assert.True(t, ok) {
+		assert.Equal(t, "path", bindErr.Source)
+	}
+
+	err = c.BindHeader(&updateUserReq{})
+	errs, ok := err.(ValidationErrors)
+	if assert.True(t, ok) && assert.Len(t, errs, 2) {
+		assert.Equal(t, "X-Token", errs[0].Field)
+	}
+
+	session := struct {
+		SID string `cookie:"sid"`
+	}{}
+	r.AddCookie(&http.Cookie{Name: "sid", Value: "s2"})
+	assert.Nil(t, c.BindCookie(&session))
+	assert.Equal(t, "s2", session.SID)
+}
+
+func panicHandler(c *Context) {
+	panic("boom")
+}
+
+func TestParseForm(t *testing.T) {
+	for _, method := range []string{"PUT", "PATCH", "DELETE"} {
+		r, _ := http.NewRequest(method, "/user?id=1", strings.NewReader("name=gwf"))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		c := newCtx(nil, r)
+		assert.Nil(t, c.ParseForm(), method)
+		assert.Equal(t, "gwf", c.ParamString("name"), method)
+		assert.Equal(t, "gwf", c.FormString("name"), method)
+		assert.Equal(t, 1, c.ParamInt("id"), method)
+	}
+
+	// 没有body的POST请求不再panic
+	r, _ := http.NewRequest("POST", "/user", nil)
+	c := newCtx(nil, r)
+	assert.Equal(t, "", c.QueryString("name"))
+
+	r, _ = http.NewRequest("POST", "/user", strings.NewReader("x"))
+	r.Header.Set("Content-Type", "multipart/form-data")
+	c = newCtx(nil, r)
+	err := c.ParseForm()
+	parseErr, ok := err.(*ParseError)
+	if assert.True(t, ok) {
+		assert.Equal(t, http.StatusBadRequest, parseErr.Code)
+	}
+	// 只解析一次
+	assert.Equal(t, err, c.ParseForm())
+	assert.Panics(t, func() { c.FormString("name") })
+
+	app := newTestApplication()
+	app.SetMaxBodySize(8)
+	app.POST("/user", Recovery(), func(c *Context) {
+		c.String(http.StatusOK, c.FormString("name"))
+	})
+	app.PUT("/user", func(c *Context) {
+		err := c.ParseForm()
+		if assert.NotNil(t, err) {
+			c.String(err.(*ParseError).Code, err.Error())
+		}
+	})
+	for _, method := range []string{"POST", "PUT"} {
+		r, _ = http.NewRequest(method, "/user", strings.NewReader("name=0123456789"))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code, method)
+	}
+
+	r, _ = http.NewRequest("POST", "/user", strings.NewReader("name=gwf"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "gwf", w.Body.String())
+
+	// 没有Recovery时ServeHTTP同样响应400和413
+	app.POST("/param", func(c *Context) {
+		c.String(http.StatusOK, c.ParamString("a"))
+	})
+	app.PATCH("/json", func(c *Context) {
+		err := c.BindJSON(&person{})
+		parseErr, ok := err.(*ParseError)
+		if assert.True(t, ok) {
+			c.String(parseErr.Code, err.Error())
+		}
+	})
+	tests := []struct {
+		method, path, contentType, body string
+		code                            int
+	}{
+		{"POST", "/param", "application/x-www-form-urlencoded", "a=%zz", http.StatusBadRequest},
+		{"POST", "/param", "application/x-www-form-urlencoded", "a=0123456789", http.StatusRequestEntityTooLarge},
+		{"PATCH", "/json", "application/json", `{"name":"0123456789"}`, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		r, _ = http.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		r.Header.Set("Content-Type", tt.contentType)
+		w = httptest.NewRecorder()
+		assert.NotPanics(t, func() { app.ServeHTTP(w, r) }, tt.body)
+		assert.Equal(t, tt.code, w.Code, tt.body)
+	}
+
+	// 其他panic继续抛出，抛出之前记录panic发生位置的调用栈
+	var logs bytes.Buffer
+	app.Logger = log.New(&logs, "", 0)
+	app.GET("/panic", panicHandler)
+	r, _ = http.NewRequest("GET", "/panic", nil)
+	assert.PanicsWithValue(t, "boom", func() { app.ServeHTTP(httptest.NewRecorder(), r) })
+	assert.Contains(t, logs.String(), "panicHandler")
+
+	// 默认不限制body的大小
+	app = newTestApplication()
+	app.POST("/json", func(c *Context) {
+		p := person{}
+		assert.Nil(t, c.BindJSON(&p))
+		c.String(http.StatusOK, strconv.Itoa(len(p.Name)))
+	})
+	r, _ = http.NewRequest("POST", "/json", strings.NewReader(`{"name":"`+strings.Repeat("x", 40<<20)+`"}`))
+	r.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, strconv.Itoa(40<<20), w.Body.String())
+}
+
+func TestContextCancel(t *testing.T) {
+	var _ context.Context = &Context{}
+
+	type ctxKey struct{}
+	r, _ := http.NewRequest("GET", "/user", nil)
+	r = r.WithContext(context.WithValue(r.Context(), ctxKey{}, "request"))
+	c := newCtx(nil, r)
+	c.Set("user", "gwf")
+	assert.Equal(t, "request", c.Value(ctxKey{}))
+	assert.Equal(t, "gwf", c.Value("user"))
+	assert.Nil(t, c.Value("none"))
+	assert.Nil(t, c.Err())
+
+	// 客户端断开连接后不再执行剩余的handlers
+	ctx, cancel := context.WithCancel(context.Background())
+	r, _ = http.NewRequest("GET", "/user", nil)
+	c = newCtx(nil, r.WithContext(ctx))
+	var called []int
+	c.handlers = HandlersChain{
+		func(c *Context) { called = append(called, 1); cancel() },
+		func(c *Context) { called = append(called, 2) },
+	}
+	c.Next()
+	assert.Equal(t, []int{1}, called)
+	assert.True(t, c.IsAborted())
+	assert.Equal(t, context.Canceled, c.Err())
+
+	app := newTestApplication()
+	app.GET("/slow", func(c *Context) {
+		_, ok := c.Deadline()
+		assert.True(t, ok)
+		<-c.Done()
+	}, func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	}).Timeout(10 * time.Millisecond)
+	w := httptest.NewRecorder()
+	r, _ = http.NewRequest("GET", "/slow", nil)
+	app.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
 func TestHeader(t *testing.T) {
 	r, _ := http.NewRequest("GET", "/header", nil)
 	ctx := newCtx(nil, r)
@@ -332,3 +638,22 @@ func TestHeader(t *testing.T) {
 	h1 := ctx.Writer.Header().Get("flag")
 	assert.Equal(t, h1, "gopher")
 }
+
+func TestPathParams(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/user/1234/3.2/true", nil)
+	c := newCtx(nil, r)
+	c.Params = Params{{"id", "1234"}, {"score", "3.2"}, {"ok", "true"}}
+
+	assert.Equal(t, "1234", c.PathParam("id"))
+	assert.Equal(t, "def", c.PathParamDefault("z", "def"))
+	assert.Equal(t, 1234, c.PathParamInt("id"))
+	assert.Equal(t, 99, c.PathParamIntDefault("z", 99))
+	assert.EqualValues(t, 0, c.PathParamInt8("id"))
+	assert.EqualValues(t, 1234, c.PathParamInt64("id"))
+	assert.EqualValues(t, 1234, c.PathParamUint32("id"))
+	assert.EqualValues(t, 7, c.PathParamUint8Default("z", 7))
+	assert.Equal(t, float32(3.2), c.PathParamFloat32("score"))
+	assert.Equal(t, 3.2, c.PathParamFloat64("score"))
+	assert.Equal(t, true, c.PathParamBool("ok"))
+	assert.Equal(t, true, c.PathParamBoolDefault("z", true))
+}
@@ -1,7 +1,10 @@
 package gwf
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
 	"reflect"
 	"runtime"
 	"strings"
@@ -9,26 +12,105 @@ import (
 
 const INIT_METHOD_NAME = "Init"
 
-const (
-	controllerTypeApi = iota
-	controllerTypeAdmin
-)
+// contextPtrType action方法参数*Context的类型
+var contextPtrType = reflect.TypeOf(&Context{})
+
+// errorType action方法返回值error的类型
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
 
 // IController Controller 接口
+// 每个请求都会创建新的controller，先调用Setup再调用Init
+// 嵌入的base controller实现IController即可，可以定义自己的base controller:
+//  type OpenAPIController struct {
+//  	*gwf.Controller
+//  	AppKey string
+//  }
+//
+//  func (c *OpenAPIController) Setup(ctx *gwf.Context) {
+//  	c.Controller.Setup(ctx)
+//  	c.AppKey = ctx.Request.Header.Get("X-App-Key")
+//  }
+// 嵌入的base controller是nil指针时，dispatcher会先创建base controller
 type IController interface {
+	// Setup 设置当前请求的Context，在Init之前调用
+	Setup(c *Context)
 	Init()
 	GetApplication() *Application
 }
 
+// iControllerType IController接口的类型
+var iControllerType = reflect.TypeOf((*IController)(nil)).Elem()
+
+// ControllerPather 自定义controller的url路径，RegisterDynamicRouter会使用ControllerPath()的返回值作为路径
+//  func (c *UserController) ControllerPath() string {
+//  	return "/v2/user"
+//  }
+type ControllerPather interface {
+	ControllerPath() string
+}
+
+// BeforeActionFilter controller实现此接口后，每次调用action方法之前都会先调用BeforeAction
+// BeforeAction中调用c.Abort()或者c.AbortWithStatus()等方法终止请求后，不会再调用action方法和AfterAction
+//  func (c *AdminUserController) BeforeAction(ctx *gwf.Context) {
+//  	if !isLogin(ctx) {
+//  		ctx.AbortWithStatus(http.StatusUnauthorized)
+//  	}
+//  }
+type BeforeActionFilter interface {
+	BeforeAction(c *Context)
+}
+
+// AfterActionFilter controller实现此接口后，每次调用action方法之后都会调用AfterAction
+type AfterActionFilter interface {
+	AfterAction(c *Context)
+}
+
+// ActionFilterer 为action声明filter，key是action方法名称，"*"表示所有action
+// filter在注册路由时作为路由的中间件添加，在controller创建之前执行，"*"的filter先于具体action的filter执行
+//  func (c *AdminUserController) ActionFilters() map[string]gwf.HandlersChain {
+//  	return map[string]gwf.HandlersChain{
+//  		"*":            {checkLogin},
+//  		"DeleteAction": {checkSuperAdmin},
+//  	}
+//  }
+type ActionFilterer interface {
+	ActionFilters() map[string]HandlersChain
+}
+
+// allActionsFilterKey ActionFilters中对所有action生效的key
+const allActionsFilterKey = "*"
+
+// actionFilters 返回controller为action声明的filter
+func actionFilters(controller interface{}, actionMethodName string) HandlersChain {
+	filterer, ok := controller.(ActionFilterer)
+	if !ok {
+		return nil
+	}
+	filters := filterer.ActionFilters()
+	var handlers HandlersChain
+	handlers = append(handlers, filters[allActionsFilterKey]...)
+	handlers = append(handlers, filters[actionMethodName]...)
+	return handlers
+}
+
 // Controller，api的controller使用这个
 type Controller struct {
 	*log.Logger
 	app *Application
 }
 
+// Setup 使用请求所属的app
+func (c *Controller) Setup(ctx *Context) {
+	if ctx != nil {
+		c.app = ctx.app
+	}
+}
+
 // Init Controller 初始化
 func (c *Controller) Init() {
-	c.app = GetApplication()
+	if c.app == nil {
+		c.app = GetApplication()
+	}
 	c.Logger = c.app.Logger
 }
 
@@ -43,9 +125,18 @@ type AdminController struct {
 	app *Application
 }
 
+// Setup 使用请求所属的app
+func (c *AdminController) Setup(ctx *Context) {
+	if ctx != nil {
+		c.app = ctx.app
+	}
+}
+
 // Init AdminController 初始化
 func (c *AdminController) Init() {
-	c.app = GetApplication()
+	if c.app == nil {
+		c.app = GetApplication()
+	}
 	c.Logger = c.app.Logger
 }
 
@@ -65,17 +156,13 @@ func isController(controller interface{}) bool {
 	}
 
 	controllerType := reflect.ValueOf(controller).Elem().Type()
+	if controllerType.Kind() != reflect.Struct {
+		return false
+	}
 
-	//是否embeding了*gwf.Controller或者*gwf.AdminController
-	baseController := reflect.ValueOf(controller).Elem().FieldByName("Controller")
-	if !baseController.IsValid() || baseController.Kind() != reflect.Ptr ||
-		baseController.Type().Elem().Name() != "Controller" {
-
-		baseController := reflect.ValueOf(controller).Elem().FieldByName("AdminController")
-		if !baseController.IsValid() || baseController.Kind() != reflect.Ptr ||
-			baseController.Type().Elem().Name() != "AdminController" {
-			return false
-		}
+	//是否实现了IController，一般是embeding了*gwf.Controller、*gwf.AdminController或者自定义的base controller
+	if !reflect.TypeOf(controller).Implements(iControllerType) {
+		return false
 	}
 
 	//是否是XXXController
@@ -92,69 +179,236 @@ func isControllerActionMethod(actionMethod interface{}) bool {
 	if !strings.HasSuffix(actionMethodName, "Action") {
 		return false
 	}
-	if actionFuncType.NumIn() != 2 {
-		return false
-	}
 
-	param := actionFuncType.In(1)
-	k := param.Kind()
-	if k != reflect.Ptr {
+	return isActionMethodType(actionFuncType)
+}
+
+// isActionMethodType 判断方法表达式的类型(第一个参数是receiver)是否是合法的action签名:
+//  func (ctl *X) ListAction(c *gwf.Context)
+//  func (ctl *X) DeleteAction(c *gwf.Context, req *DeleteReq) error
+//  func (ctl *X) CreateAction(c *gwf.Context, req *CreateReq) (*CreateResp, error)
+// req必须是struct的指针，可以省略
+func isActionMethodType(t reflect.Type) bool {
+	if t.Kind() != reflect.Func || t.NumIn() < 2 || t.NumIn() > 3 || t.In(1) != contextPtrType {
 		return false
 	}
+	if t.NumIn() == 3 {
+		req := t.In(2)
+		if req.Kind() != reflect.Ptr || req.Elem().Kind() != reflect.Struct {
+			return false
+		}
+	}
 
-	param = param.Elem()
-	if param.Name() != "Context" || !strings.HasSuffix(param.PkgPath(), "github.com/gwf") {
-		return false
+	switch t.NumOut() {
+	case 0:
+		return true
+	case 1:
+		return t.Out(0) == errorType
+	case 2:
+		return t.Out(1) == errorType
 	}
+	return false
+}
 
-	return true
+// actionInvoker 调用controller的action方法，注册路由时创建，反射信息只计算一次
+// 每个请求会创建新的controller实例，设置base controller、注入依赖并调用Init后再调用action方法
+type actionInvoker struct {
+	controllerType reflect.Type
+	// bases 需要创建的base controller字段，外层的字段在前
+	bases  []baseControllerField
+	action reflect.Value
+	// hasBefore controller实现了BeforeActionFilter
+	hasBefore bool
+	// hasAfter controller实现了AfterActionFilter
+	hasAfter bool
+	// reqType action请求参数的struct类型，action没有请求参数时为nil
+	reqType reflect.Type
+	// injects 需要注入依赖的字段
+	injects []injectField
 }
 
-func callActionMethod(c *Context, actionMethod interface{}) {
-	actionMethodType := reflect.TypeOf(actionMethod)
-	actionMethodName := runtime.FuncForPC(reflect.ValueOf(actionMethod).Pointer()).Name()
-	actionMethodNameSlice := strings.Split(actionMethodName, ".")
-	actionMethodName = actionMethodNameSlice[len(actionMethodNameSlice)-1]
+// baseControllerField controller中嵌入的base controller指针字段
+type baseControllerField struct {
+	index []int
+	typ   reflect.Type
+}
+
+// baseControllerFields 查找t中嵌入的实现了IController的指针字段，包括base controller中嵌入的base controller
+func baseControllerFields(t reflect.Type, index []int) []baseControllerField {
+	var bases []baseControllerField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.Anonymous {
+			continue
+		}
+		fieldIndex := append(append([]int(nil), index...), i)
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			if fieldType.Elem().Kind() != reflect.Struct || !fieldType.Implements(iControllerType) {
+				continue
+			}
+			if field.PkgPath != "" {
+				panic("base controller必须是导出的类型 type:" + fieldType.String())
+			}
+			bases = append(bases, baseControllerField{index: fieldIndex, typ: fieldType.Elem()})
+			fieldType = fieldType.Elem()
+		} else if fieldType.Kind() != reflect.Struct {
+			continue
+		}
+		bases = append(bases, baseControllerFields(fieldType, fieldIndex)...)
+	}
+	return bases
+}
+
+// newActionInvoker 为action方法创建actionInvoker，actionMethod是方法表达式，比如(*UserController).ListAction
+func newActionInvoker(actionMethod interface{}) *actionInvoker {
+	actionMethodValue := reflect.ValueOf(actionMethod)
+	actionMethodType := actionMethodValue.Type()
+	if !isActionMethodType(actionMethodType) {
+		panic("Action定义错误")
+	}
 	param := actionMethodType.In(0)
-	k := param.Kind()
-	if k != reflect.Ptr {
+	if param.Kind() != reflect.Ptr || param.Elem().Kind() != reflect.Struct {
 		panic("Action定义错误")
 	}
 	controllerType := param.Elem()
-	controller := reflect.New(controllerType)
-
-	baseControllerValue := controller.Elem().FieldByName("Controller")
-	baseControllerType := controllerTypeApi
-	if !baseControllerValue.IsValid() {
-		baseControllerValue = controller.Elem().FieldByName("AdminController")
-		if !baseControllerValue.IsValid() {
-			panic("Controller定义错误，目前只支持Controller和AdminController")
+	if !param.Implements(iControllerType) {
+		panic("Controller定义错误，controller需要实现IController controller:" + param.String())
+	}
+
+	var reqType reflect.Type
+	if actionMethodType.NumIn() == 3 {
+		reqType = actionMethodType.In(2).Elem()
+	}
+
+	return &actionInvoker{
+		controllerType: controllerType,
+		bases:          baseControllerFields(controllerType, nil),
+		action:         actionMethodValue,
+		hasBefore:      param.Implements(reflect.TypeOf((*BeforeActionFilter)(nil)).Elem()),
+		hasAfter:       param.Implements(reflect.TypeOf((*AfterActionFilter)(nil)).Elem()),
+		reqType:        reqType,
+		injects:        injectFields(controllerType),
+	}
+}
+
+func (ai *actionInvoker) invoke(c *Context) {
+	controller := reflect.New(ai.controllerType)
+	for _, base := range ai.bases {
+		controller.Elem().FieldByIndex(base.index).Set(reflect.New(base.typ))
+	}
+	for _, field := range ai.injects {
+		value, err := c.app.resolve(field.typ, c, nil)
+		if err != nil {
+			panic(fmt.Sprintf("注入依赖失败 controller:%s field:%s err:%s", ai.controllerType, field.name, err))
+		}
+		controller.Elem().FieldByIndex(field.index).Set(value)
+	}
+	instance := controller.Interface()
+	ic := instance.(IController)
+	ic.Setup(c)
+	ic.Init()
+	if ai.hasBefore {
+		instance.(BeforeActionFilter).BeforeAction(c)
+		if c.IsAborted() {
+			return
 		}
-		baseControllerType = controllerTypeAdmin
 	}
 
-	var baseController IController
-	switch baseControllerType {
-	case controllerTypeApi:
-		baseController = &Controller{}
-	case controllerTypeAdmin:
-		baseController = &AdminController{}
-	default:
-		panic("BaseController错误")
+	var args [3]reflect.Value
+	in := append(args[:0], controller, reflect.ValueOf(c))
+	if ai.reqType != nil {
+		req := reflect.New(ai.reqType)
+		if err := bindActionRequest(c, req.Interface()); err != nil {
+			// validate tag错误是服务端的错误，由renderActionError记录日志并响应500
+			var tagErr *ValidateTagError
+			if _, ok := clientError(c, err); !ok && !errors.As(err, &tagErr) {
+				err = NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+			renderActionError(c, err)
+			return
+		}
+		in = append(in, req)
+	}
+	out := ai.action.Call(in)
+	if ai.hasAfter {
+		instance.(AfterActionFilter).AfterAction(c)
+	}
+	if len(out) > 0 {
+		renderActionResult(c, out)
 	}
+}
+
+// Validator typed action的请求参数实现此接口后，绑定参数之后会调用Validate校验，返回错误时响应400
+type Validator interface {
+	Validate() error
+}
 
-	baseControllerValue.Set(reflect.ValueOf(baseController))
-	initMethod := controller.MethodByName(INIT_METHOD_NAME)
-	if initMethod.IsValid() {
-		//定义了Init方法
-		initMethod.Call([]reflect.Value{})
+// bindActionRequest 绑定typed action的请求参数，详见Context.BindRequest
+// 绑定之后先使用validate tag校验，再调用Validator的Validate
+func bindActionRequest(c *Context, dst interface{}) error {
+	if err := c.BindRequest(dst); err != nil {
+		return err
+	}
+	if v, ok := dst.(Validator); ok {
+		return v.Validate()
 	}
+	return nil
+}
 
-	m := controller.MethodByName(actionMethodName)
-	if m.IsValid() {
-		m.Call([]reflect.Value{reflect.ValueOf(c)})
-	} else {
-		//不会走到这里来
-		panic("未定义")
+// renderActionResult 输出typed action的返回值
+// 返回的error不为nil时输出错误，返回值为nil时响应204，否则按请求的Accept输出返回值
+func renderActionResult(c *Context, out []reflect.Value) {
+	if errValue := out[len(out)-1]; !errValue.IsNil() {
+		renderActionError(c, errValue.Interface().(error))
+		return
+	}
+	if len(out) == 1 {
+		return
+	}
+
+	result := out[0]
+	switch result.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		if result.IsNil() {
+			c.Status(http.StatusNoContent)
+			c.Writer.WriteHeaderNow()
+			return
+		}
+	}
+	c.Negotiate(http.StatusOK, result.Interface())
+}
+
+// clientError 将客户端错误转换为*HTTPError，err不是客户端错误时ok为false
+//  *HTTPError       不转换
+//  *BindError       400
+//  *ParseError      400，body超过大小限制时413
+//  ValidationErrors 400，错误信息的语言由请求的Accept-Language决定
+func clientError(c *Context, err error) (httpErr *HTTPError, ok bool) {
+	var bindErr *BindError
+	var parseErr *ParseError
+	var validationErrs ValidationErrors
+	switch {
+	case errors.As(err, &httpErr):
+		return httpErr, true
+	case errors.As(err, &parseErr):
+		return NewHTTPError(parseErr.Code, parseErr.Error()), true
+	case errors.As(err, &bindErr):
+		return NewHTTPError(http.StatusBadRequest, bindErr.Error()), true
+	case errors.As(err, &validationErrs):
+		return NewHTTPError(http.StatusBadRequest, validationErrs.Message(c.Lang())), true
+	}
+	return nil, false
+}
+
+// renderActionError 输出错误，客户端错误详见clientError，其他错误记录日志后响应500
+func renderActionError(c *Context, err error) {
+	httpErr, ok := clientError(c, err)
+	if !ok {
+		if c.app != nil {
+			c.app.Logger.Printf("action返回错误 url:%s err:%s", c.Request.URL.Path, err)
+		}
+		httpErr = NewHTTPError(http.StatusInternalServerError, "")
 	}
+	c.Negotiate(httpErr.Code, httpErr)
 }
@@ -1,12 +1,19 @@
 package gwf
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"runtime"
 	"strings"
 	"testing"
 
 	"github.com/iancoleman/strcase"
+	"github.com/stretchr/testify/assert"
 )
 
 type NotControllerA struct {
@@ -79,5 +86,305 @@ func TestIsControllerActionMethod(t *testing.T) {
 
 func TestCallActionMethod(t *testing.T) {
 	initTestAppConfig(t)
-	callActionMethod(nil, (*SomeController).SomeAction)
+	newActionInvoker((*SomeController).SomeAction).invoke(nil)
+}
+
+type benchController struct {
+	*Controller
+}
+
+func (controller *benchController) Init() {}
+
+func (controller *benchController) HelloAction(c *Context) {}
+
+func TestActionInvoker(t *testing.T) {
+	invoker := newActionInvoker((*SomeController).SomeAction)
+	assert.Equal(t, reflect.TypeOf(SomeController{}), invoker.controllerType)
+	assert.Equal(t, []baseControllerField{{index: []int{0}, typ: reflect.TypeOf(Controller{})}}, invoker.bases)
+
+	assert.Panics(t, func() { newActionInvoker((*NotControllerA).Init) })
+	assert.Panics(t, func() { newActionInvoker(func(c *Context) {}) })
+}
+
+const (
+	legacyControllerTypeApi = iota
+	legacyControllerTypeAdmin
+)
+
+// legacyCallActionMethod 使用actionInvoker之前的callActionMethod，原样保留用于对比性能
+// 每个请求都通过方法名查找Init和action方法
+func legacyCallActionMethod(c *Context, actionMethod interface{}) {
+	actionMethodType := reflect.TypeOf(actionMethod)
+	actionMethodName := runtime.FuncForPC(reflect.ValueOf(actionMethod).Pointer()).Name()
+	actionMethodNameSlice := strings.Split(actionMethodName, ".")
+	actionMethodName = actionMethodNameSlice[len(actionMethodNameSlice)-1]
+	param := actionMethodType.In(0)
+	k := param.Kind()
+	if k != reflect.Ptr {
+		panic("Action定义错误")
+	}
+	controllerType := param.Elem()
+	controller := reflect.New(controllerType)
+
+	baseControllerValue := controller.Elem().FieldByName("Controller")
+	baseControllerType := legacyControllerTypeApi
+	if !baseControllerValue.IsValid() {
+		baseControllerValue = controller.Elem().FieldByName("AdminController")
+		if !baseControllerValue.IsValid() {
+			panic("Controller定义错误，目前只支持Controller和AdminController")
+		}
+		baseControllerType = legacyControllerTypeAdmin
+	}
+
+	var baseController IController
+	switch baseControllerType {
+	case legacyControllerTypeApi:
+		baseController = &Controller{}
+	case legacyControllerTypeAdmin:
+		baseController = &AdminController{}
+	default:
+		panic("BaseController错误")
+	}
+
+	baseControllerValue.Set(reflect.ValueOf(baseController))
+	initMethod := controller.MethodByName(INIT_METHOD_NAME)
+	if initMethod.IsValid() {
+		//定义了Init方法
+		initMethod.Call([]reflect.Value{})
+	}
+
+	m := controller.MethodByName(actionMethodName)
+	if m.IsValid() {
+		m.Call([]reflect.Value{reflect.ValueOf(c)})
+	} else {
+		//不会走到这里来
+		panic("未定义")
+	}
+}
+
+func BenchmarkLegacyCallActionMethod(b *testing.B) {
+	c := &Context{}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		legacyCallActionMethod(c, (*benchController).HelloAction)
+	}
+}
+
+func BenchmarkActionInvoker(b *testing.B) {
+	c := &Context{}
+	invoker := newActionInvoker((*benchController).HelloAction)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		invoker.invoke(c)
+	}
+}
+
+type filterController struct {
+	*Controller
+}
+
+func (controller *filterController) Init() {}
+
+func (controller *filterController) ActionFilters() map[string]HandlersChain {
+	return map[string]HandlersChain{
+		"*": {func(c *Context) {
+			c.Writer.Header().Add("X-Filter", "all")
+		}},
+		"DeleteAction": {func(c *Context) {
+			c.Writer.Header().Add("X-Filter", "delete")
+		}},
+	}
+}
+
+func (controller *filterController) BeforeAction(c *Context) {
+	if c.QueryString("token") == "" {
+		c.AbortWithStatusString(http.StatusUnauthorized, "unauthorized")
+	}
+}
+
+func (controller *filterController) AfterAction(c *Context) {
+	c.Writer.Header().Add("X-After", "yes")
+}
+
+func (controller *filterController) ShowAction(c *Context) {
+	c.String(http.StatusOK, "show")
+}
+
+func (controller *filterController) DeleteAction(c *Context) {
+	c.String(http.StatusOK, "delete")
+}
+
+func TestActionFilters(t *testing.T) {
+	app := newTestApplication()
+	app.RegisterDynamicRouter(&filterController{}, "", []string{"GET"})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/github.com/panda-win/gwf/filter/show", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, []string{"all"}, w.Header()["X-Filter"])
+	assert.Empty(t, w.Header().Get("X-After"))
+
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/github.com/panda-win/gwf/filter/show?token=1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "show", w.Body.String())
+	assert.Equal(t, "yes", w.Header().Get("X-After"))
+
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/github.com/panda-win/gwf/filter/delete?token=1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"all", "delete"}, w.Header()["X-Filter"])
+}
+
+type createArticleReq struct {
+	Title string `form:"title" json:"title"`
+	Draft bool   `form:"draft" json:"draft"`
+}
+
+func (req *createArticleReq) Validate() error {
+	if req.Title == "" {
+		return errors.New("title不能为空")
+	}
+	return nil
+}
+
+type createArticleResp struct {
+	Title string `json:"title" xml:"title"`
+	Draft bool   `json:"draft" xml:"draft"`
+}
+
+type typedController struct {
+	*Controller
+}
+
+func (controller *typedController) Init() {}
+
+func (controller *typedController) CreateAction(c *Context, req *createArticleReq) (*createArticleResp, error) {
+	if req.Title == "forbidden" {
+		return nil, NewHTTPError(http.StatusForbidden, "")
+	}
+	if req.Title == "broken" {
+		return nil, errors.New("db error")
+	}
+	if req.Title == "empty" {
+		return nil, nil
+	}
+	return &createArticleResp{Title: req.Title, Draft: req.Draft}, nil
+}
+
+func TestTypedAction(t *testing.T) {
+	assert.True(t, isControllerActionMethod((*typedController).CreateAction))
+
+	app := newTestApplication()
+	app.RegisterDynamicRouter(&typedController{}, "", []string{"POST"})
+	url := "/github.com/panda-win/gwf/typed/create"
+
+	tests := []struct {
+		query  string
+		body   string
+		accept string
+		code   int
+		resp   string
+	}{
+		{"", `{"title":"go","draft":true}`, "", http.StatusOK, `{"title":"go","draft":true}`},
+		{"?draft=true", `{"title":"go"}`, "", http.StatusOK, `{"title":"go","draft":true}`},
+		{"", `{"title":"go"}`, "application/xml", http.StatusOK, `<createArticleResp><title>go</title><draft>false</draft></createArticleResp>`},
+		{"", `{}`, "", http.StatusBadRequest, `{"code":400,"message":"title不能为空"}`},
+		{"", `{"title":`, "", http.StatusBadRequest, `{"code":400,"message":"绑定json参数失败 err:unexpected EOF"}`},
+		{"", `{"title":"forbidden"}`, "text/xml", http.StatusForbidden, `<error><code>403</code><message>Forbidden</message></error>`},
+		{"", `{"title":"broken"}`, "", http.StatusInternalServerError, `{"code":500,"message":"Internal Server Error"}`},
+		{"", `{"title":"empty"}`, "", http.StatusNoContent, ``},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", url+tt.query, strings.NewReader(tt.body))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("Accept", tt.accept)
+		app.ServeHTTP(w, r)
+		assert.Equal(t, tt.code, w.Code, tt.body)
+		assert.Equal(t, tt.resp, w.Body.String(), tt.body)
+	}
+}
+
+type badTagReq struct {
+	Title string `json:"title" validate:"required,unknown"`
+}
+
+type badTagController struct {
+	*Controller
+}
+
+func (controller *badTagController) Init() {}
+
+func (controller *badTagController) CreateAction(c *Context, req *badTagReq) error {
+	return nil
+}
+
+func TestTypedActionTagError(t *testing.T) {
+	// validate tag错误是服务端的错误，记录日志并响应500，不能把错误信息返回给客户端
+	app := newTestApplication()
+	var logs bytes.Buffer
+	app.Logger = log.New(&logs, "", 0)
+	app.RegisterDynamicRouter(&badTagController{}, "", []string{"POST"})
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/github.com/panda-win/gwf/bad_tag/create", strings.NewReader(`{"title":"go"}`))
+	r.Header.Set("Content-Type", "application/json")
+	app.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, `{"code":500,"message":"Internal Server Error"}`, w.Body.String())
+	assert.Contains(t, logs.String(), "校验规则错误")
+}
+
+type OpenAPIController struct {
+	*Controller
+	AppKey string
+}
+
+func (controller *OpenAPIController) Setup(c *Context) {
+	controller.Controller.Setup(c)
+	controller.AppKey = c.Request.Header.Get("X-App-Key")
+}
+
+func (controller *OpenAPIController) Init() {}
+
+type openController struct {
+	*OpenAPIController
+}
+
+func (controller *openController) KeyAction(c *Context) {
+	c.String(http.StatusOK, controller.AppKey+" "+controller.GetApplication().config.Name)
+}
+
+type selfController struct{}
+
+func (controller *selfController) Setup(c *Context) {}
+
+func (controller *selfController) Init() {}
+
+func (controller *selfController) GetApplication() *Application {
+	return nil
+}
+
+func (controller *selfController) PingAction(c *Context) {
+	c.String(http.StatusOK, "pong")
+}
+
+func TestCustomBaseController(t *testing.T) {
+	assert.True(t, isController(&openController{}))
+	assert.True(t, isController(&selfController{}))
+
+	app := newTestApplication()
+	app.RegisterDynamicRouter(&openController{}, "", []string{"GET"})
+	app.RegisterDynamicRouter(&selfController{}, "", []string{"GET"})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/github.com/panda-win/gwf/open/key", nil)
+	r.Header.Set("X-App-Key", "abc")
+	app.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "abc gwf", w.Body.String())
+
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/github.com/panda-win/gwf/self/ping", nil))
+	assert.Equal(t, "pong", w.Body.String())
 }
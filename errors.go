@@ -1,5 +1,12 @@
 package gwf
 
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
 type ErrorType uint8
 
 const (
@@ -12,3 +19,85 @@ type Error struct {
 	Type  ErrorType
 	Stack string
 }
+
+// HTTPError 带有http状态码的错误
+// typed action返回此错误时，使用Code作为响应状态码，并将错误按请求的Accept输出
+//  return nil, gwf.NewHTTPError(http.StatusNotFound, "文章不存在")
+type HTTPError struct {
+	XMLName xml.Name `json:"-" xml:"error"`
+	Code    int      `json:"code" xml:"code"`
+	Message string   `json:"message" xml:"message"`
+}
+
+// NewHTTPError 创建HTTPError，message为空时使用http.StatusText(code)
+func NewHTTPError(code int, message string) *HTTPError {
+	if message == "" {
+		message = http.StatusText(code)
+	}
+	return &HTTPError{Code: code, Message: message}
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("http error code:%d message:%s", e.Code, e.Message)
+}
+
+// BindError 参数绑定错误，Source是参数的来源，比如form、json、xml、yaml
+// typed action返回此错误时响应400
+type BindError struct {
+	Source string
+	Err    error
+}
+
+func (e *BindError) Error() string {
+	return fmt.Sprintf("绑定%s参数失败 err:%s", e.Source, e.Err)
+}
+
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateTagError validate tag定义错误，比如未定义的规则、参数错误以及字段类型不支持规则
+// 这是服务端的错误，typed action绑定参数返回此错误时记录日志并响应500，不会把错误信息返回给客户端
+type ValidateTagError struct {
+	Struct string
+	Field  string
+	Err    error
+}
+
+func (e *ValidateTagError) Error() string {
+	return fmt.Sprintf("校验规则错误 struct:%s field:%s err:%s", e.Struct, e.Field, e.Err)
+}
+
+func (e *ValidateTagError) Unwrap() error {
+	return e.Err
+}
+
+// ErrEmptyBody 请求body为空
+var ErrEmptyBody = errors.New("请求body为空")
+
+// ErrBodyTooLarge 请求body超过Application.SetMaxBodySize设置的大小
+var ErrBodyTooLarge = errors.New("请求body超过大小限制")
+
+// ParseError 解析请求body失败，Code是对应的http状态码，body超过大小限制时是413，其他是400
+// typed action返回此错误时使用Code作为响应状态码，ParamXXX、FormXXX等函数panic(*ParseError)时，
+// ServeHTTP会响应Code，不需要Recovery
+type ParseError struct {
+	Code int
+	Err  error
+}
+
+func newParseError(err error) *ParseError {
+	code := http.StatusBadRequest
+	if errors.Is(err, ErrBodyTooLarge) {
+		code = http.StatusRequestEntityTooLarge
+	}
+	return &ParseError{Code: code, Err: err}
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("解析请求参数失败 err:%s", e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
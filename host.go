@@ -0,0 +1,162 @@
+package gwf
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// hostWildcardKey host中*匹配到的label在HostParams中的名称
+const hostWildcardKey = "*"
+
+// hostPattern 路由组绑定的host，按'.'切分为label逐个匹配，不区分大小写
+//  admin.example.com 只匹配admin.example.com
+//  *.tenant.example.com 匹配a.tenant.example.com，*匹配到的label名称为"*"
+//  :tenant.example.com 匹配a.example.com，匹配到的label名称为tenant
+// *和:name只匹配一个label，不能匹配多级域名
+type hostPattern struct {
+	pattern string
+	labels  []string
+}
+
+func newHostPattern(pattern string) *hostPattern {
+	pattern = strings.ToLower(strings.TrimSuffix(pattern, "."))
+	if pattern == "" {
+		panic("host pattern can not be empty")
+	}
+	labels := strings.Split(pattern, ".")
+	for _, label := range labels {
+		if label == "" || label == ":" || (strings.Contains(label, "*") && label != "*") {
+			panic("invalid host pattern " + pattern)
+		}
+	}
+	return &hostPattern{pattern: pattern, labels: labels}
+}
+
+// match 判断host是否匹配，返回*和:name匹配到的label
+func (hp *hostPattern) match(host string) (params Params, ok bool) {
+	for i, label := range hp.labels {
+		var part string
+		if i == len(hp.labels)-1 {
+			part = host
+			host = ""
+		} else {
+			dot := strings.IndexByte(host, '.')
+			if dot < 0 {
+				return nil, false
+			}
+			part = host[:dot]
+			host = host[dot+1:]
+		}
+		if part == "" {
+			return nil, false
+		}
+		switch {
+		case label == "*":
+			params = append(params, Param{Key: hostWildcardKey, Value: part})
+		case label[0] == ':':
+			params = append(params, Param{Key: label[1:], Value: part})
+		case !strings.EqualFold(label, part):
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// overlap 判断两个host是否可能匹配同一个请求host
+func (hp *hostPattern) overlap(other *hostPattern) bool {
+	if len(hp.labels) != len(other.labels) {
+		return false
+	}
+	for i, label := range hp.labels {
+		otherLabel := other.labels[i]
+		if label == "*" || label[0] == ':' || otherLabel == "*" || otherLabel[0] == ':' {
+			continue
+		}
+		if label != otherLabel {
+			return false
+		}
+	}
+	return true
+}
+
+// requestHost 返回请求的host，不包含端口
+func requestHost(r *http.Request) string {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(host, ".")
+}
+
+// SetHost 将路由组绑定到host，只有host匹配的请求才会在此路由组中匹配路由
+// 绑定host的路由组优先于未绑定host的路由组匹配，host中*和:name匹配到的label可以通过Context.HostParam获取:
+//  rg := gwf.NewRouterGroup(app, "tenant")
+//  rg.SetHost(":tenant.example.com")
+//  rg.GET("/", func(c *gwf.Context) {
+//  	c.String(200, c.HostParam("tenant"))
+//  })
+//  app.AddRouterGroup(rg)
+// 通过Group创建的子路由组会继承host，请在创建子路由组之前调用此方法
+func (rg *RouterGroup) SetHost(pattern string) {
+	rg.host = newHostPattern(pattern)
+}
+
+// routerGroupAt 按匹配顺序返回第i个路由组，0是app级别路由组
+func (app *Application) routerGroupAt(i int) *RouterGroup {
+	if i == 0 {
+		return app.RouterGroup
+	}
+	return app.otherRouterGroups[i-1]
+}
+
+// hostRouterGroups 返回可以处理host的所有路由组以及各自的host参数
+// 绑定host的路由组在前，未绑定host的路由组在后，同一类路由组按注册顺序排列
+func (app *Application) hostRouterGroups(host string) ([]*RouterGroup, []Params) {
+	var groups []*RouterGroup
+	var hostParams []Params
+	for round := 0; round < 2; round++ {
+		for i := 0; i <= len(app.otherRouterGroups); i++ {
+			rg := app.routerGroupAt(i)
+			if (rg.host != nil) != (round == 0) {
+				continue
+			}
+			var params Params
+			if rg.host != nil {
+				var ok bool
+				if params, ok = rg.host.match(host); !ok {
+					continue
+				}
+			}
+			groups = append(groups, rg)
+			hostParams = append(hostParams, params)
+		}
+	}
+	return groups, hostParams
+}
+
+// handleRequestByHost 先在绑定了host的路由组中匹配，再在未绑定host的路由组中匹配
+func (app *Application) handleRequestByHost(c *Context) bool {
+	host := requestHost(c.Request)
+	for round := 0; round < 2; round++ {
+		for i := 0; i <= len(app.otherRouterGroups); i++ {
+			rg := app.routerGroupAt(i)
+			if (rg.host != nil) != (round == 0) {
+				continue
+			}
+			c.HostParams = nil
+			if rg.host != nil {
+				params, ok := rg.host.match(host)
+				if !ok {
+					continue
+				}
+				c.HostParams = params
+			}
+			if rg.handleRequest(c) {
+				return true
+			}
+		}
+	}
+	c.HostParams = nil
+	return false
+}
@@ -0,0 +1,65 @@
+package gwf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostPatternMatch(t *testing.T) {
+	hp := newHostPattern("*.Tenant.example.com")
+	params, ok := hp.match("acme.tenant.EXAMPLE.com")
+	assert.True(t, ok)
+	assert.Equal(t, "acme", params.ByName("*"))
+
+	_, ok = hp.match("tenant.example.com")
+	assert.False(t, ok)
+	_, ok = hp.match("a.b.tenant.example.com")
+	assert.False(t, ok)
+
+	hp = newHostPattern(":tenant.example.com")
+	params, ok = hp.match("acme.example.com")
+	assert.True(t, ok)
+	assert.Equal(t, "acme", params.ByName("tenant"))
+
+	assert.True(t, newHostPattern("*.example.com").overlap(newHostPattern("admin.example.com")))
+	assert.False(t, newHostPattern("api.example.com").overlap(newHostPattern("admin.example.com")))
+	assert.Panics(t, func() { newHostPattern("a*.example.com") })
+}
+
+func TestHostRouting(t *testing.T) {
+	app := newTestApplication()
+	app.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "default")
+	})
+	admin := NewRouterGroup(app, "admin")
+	admin.SetHost("admin.example.com")
+	admin.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "admin")
+	})
+	app.AddRouterGroup(admin)
+	tenant := NewRouterGroup(app, "tenant")
+	tenant.SetHost(":tenant.example.com")
+	tenant.Group("/t").GET("/", func(c *Context) {
+		c.String(http.StatusOK, "tenant "+c.HostParam("tenant"))
+	})
+	app.AddRouterGroup(tenant)
+	assert.Nil(t, app.CheckRoutes())
+
+	tests := []struct {
+		host, path, body string
+	}{
+		{"admin.example.com:8080", "/", "admin"},
+		{"www.example.com", "/", "default"},
+		{"acme.example.com", "/t", "tenant acme"},
+		{"localhost", "/t", "资源不存在"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "http://"+tt.host+tt.path, nil)
+		app.ServeHTTP(w, r)
+		assert.Equal(t, tt.body, w.Body.String(), tt.host)
+	}
+}
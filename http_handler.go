@@ -10,6 +10,11 @@ var DefaultNotFoundHandler HandlerFunc = func(c *Context) {
 	c.Bytes(http.StatusNotFound, []byte("资源不存在"))
 }
 
+// DefaultMethodNotAllowedHandler 默认405handler
+var DefaultMethodNotAllowedHandler HandlerFunc = func(c *Context) {
+	c.Bytes(http.StatusMethodNotAllowed, []byte("请求方法不允许"))
+}
+
 // DefaultInternalServerErrorHandler 默认500handler
 var DefaultInternalServerErrorHandler HandlerFunc = func(c *Context) {
 	if GetConfig().IsOnlineEnvironment() || GetConfig().IsPreEnvironment() {
@@ -2,6 +2,7 @@ package gwf
 
 import (
 	"net"
+	"net/http"
 	"net/http/httputil"
 	"os"
 	"strings"
@@ -11,6 +12,13 @@ func Recovery() HandlerFunc {
 	return func(c *Context) {
 		defer func() {
 			if err := recover(); err != nil {
+				// 解析请求参数失败是客户端的错误
+				if parseErr, ok := err.(*ParseError); ok {
+					c.app.Logger.Printf("[Recovery] [ParseError] %s url:%s", parseErr, c.Request.URL.Path)
+					c.AbortWithStatusString(parseErr.Code, http.StatusText(parseErr.Code))
+					return
+				}
+
 				// 检查连接断开的情况
 				var brokenPipe bool
 				if ne, ok := err.(*net.OpError); ok {
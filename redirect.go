@@ -0,0 +1,97 @@
+package gwf
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+// SetRedirectTrailingSlash 设置是否修正path尾部的斜杠，默认开启
+// 开启后，如果/foo/没有匹配到路由而/foo存在(或者相反)，将重定向到存在的路由
+// GET请求响应301，其他请求响应308，308会让客户端保持请求方法和body
+func (app *Application) SetRedirectTrailingSlash(enable bool) {
+	app.redirectTrailingSlash = enable
+}
+
+// SetRedirectFixedPath 设置是否修正path，默认关闭
+// 开启后，没有匹配到路由的path会先清理多余的..和//，再不区分大小写的查找路由，
+// 找到时重定向到修正后的path，比如/FOO//bar/../baz重定向到/foo/baz
+func (app *Application) SetRedirectFixedPath(enable bool) {
+	app.redirectFixedPath = enable
+}
+
+// redirectRequest 尝试将没有匹配到路由的请求重定向到修正后的path，重定向时返回true
+// 关闭了重定向的路由组(详见RouterGroup.DisableRedirect)中的路由不会作为重定向的目标
+func (app *Application) redirectRequest(c *Context) bool {
+	r := c.Request
+	reqPath := r.URL.Path
+	if r.Method == http.MethodConnect || reqPath == "/" || (!app.redirectTrailingSlash && !app.redirectFixedPath) {
+		return false
+	}
+
+	methods := []string{r.Method}
+	if r.Method == http.MethodHead {
+		methods = append(methods, http.MethodGet)
+	}
+	groups, _ := app.hostRouterGroups(requestHost(r))
+
+	if app.redirectTrailingSlash {
+		fixedPath := reqPath + "/"
+		if strings.HasSuffix(reqPath, "/") {
+			fixedPath = reqPath[:len(reqPath)-1]
+		}
+		if redirectTarget(groups, methods, fixedPath) {
+			redirectTo(c, fixedPath)
+			return true
+		}
+	}
+
+	if app.redirectFixedPath {
+		cleaned := path.Clean(reqPath)
+		candidates := []string{cleaned}
+		if app.redirectTrailingSlash && cleaned != "/" {
+			candidates = append(candidates, cleaned+"/")
+		}
+		for _, candidate := range candidates {
+			for _, rg := range groups {
+				for _, method := range methods {
+					root, ok := rg.trees[method]
+					if !ok {
+						continue
+					}
+					fixed, routeInfo := root.findCaseInsensitive(candidate, make([]byte, 0, len(candidate)))
+					if routeInfo != nil && !routeInfo.group.disableRedirect && string(fixed) != reqPath {
+						redirectTo(c, string(fixed))
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// redirectTarget 判断fixedPath是否可以作为重定向的目标
+func redirectTarget(groups []*RouterGroup, methods []string, fixedPath string) bool {
+	for _, rg := range groups {
+		for _, method := range methods {
+			if routeInfo, _ := rg.match(method, fixedPath); routeInfo != nil && !routeInfo.group.disableRedirect {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// redirectTo 重定向到fixedPath，保留url参数
+func redirectTo(c *Context, fixedPath string) {
+	code := http.StatusPermanentRedirect
+	if c.Request.Method == http.MethodGet {
+		code = http.StatusMovedPermanently
+	}
+	if c.Request.URL.RawQuery != "" {
+		fixedPath += "?" + c.Request.URL.RawQuery
+	}
+	http.Redirect(c.Writer, c.Request, fixedPath, code)
+	c.Writer.WriteHeaderNow()
+}
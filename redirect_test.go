@@ -0,0 +1,54 @@
+package gwf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedirect(t *testing.T) {
+	app := newTestApplication()
+	app.GET("/foo", func(_ *Context) {})
+	app.PUT("/foo", func(_ *Context) {})
+	app.GET("/files/*filepath", func(_ *Context) {})
+	app.GET("/User/:name/Profile", func(_ *Context) {})
+	noRedirect := app.Group("/internal")
+	noRedirect.DisableRedirect()
+	noRedirect.GET("/status", func(_ *Context) {})
+
+	tests := []struct {
+		fixedPath bool
+		method    string
+		path      string
+		code      int
+		location  string
+	}{
+		{false, "GET", "/foo/", http.StatusMovedPermanently, "/foo"},
+		{false, "GET", "/foo/?a=1", http.StatusMovedPermanently, "/foo?a=1"},
+		{false, "PUT", "/foo/", http.StatusPermanentRedirect, "/foo"},
+		{false, "GET", "/files", http.StatusMovedPermanently, "/files/"},
+		{false, "GET", "/FOO", http.StatusNotFound, ""},
+		{false, "GET", "/internal/status/", http.StatusNotFound, ""},
+		{true, "GET", "/FOO", http.StatusMovedPermanently, "/foo"},
+		{true, "GET", "/x/../foo", http.StatusMovedPermanently, "/foo"},
+		{true, "GET", "/user//Tom/profile/", http.StatusMovedPermanently, "/User/Tom/Profile"},
+		{true, "GET", "/Internal/Status", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		app.SetRedirectFixedPath(tt.fixedPath)
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest(tt.method, tt.path, nil)
+		app.ServeHTTP(w, r)
+		assert.Equal(t, tt.code, w.Code, tt.path)
+		assert.Equal(t, tt.location, w.Header().Get("Location"), tt.path)
+	}
+
+	app.SetRedirectTrailingSlash(false)
+	app.SetRedirectFixedPath(false)
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/foo/", nil)
+	app.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,383 @@
+package gwf
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-yaml/yaml"
+)
+
+// Renderer 将data编码为某种MIME类型的响应body
+// 通过Application.RegisterRenderer按MIME类型注册后，Negotiate会根据请求的Accept选择Renderer
+type Renderer interface {
+	// ContentType 响应头中的Content-Type
+	ContentType() string
+	// Render 将data编码为响应body，c是当前请求，比如JSONP需要从c中读取callback参数
+	Render(c *Context, data interface{}) ([]byte, error)
+}
+
+// JsonRenderer 输出json
+type JsonRenderer struct{}
+
+func (JsonRenderer) ContentType() string {
+	return "application/json; charset=UTF-8"
+}
+
+func (JsonRenderer) Render(c *Context, data interface{}) ([]byte, error) {
+	return json.Marshal(data)
+}
+
+// IndentedJsonRenderer 输出带缩进的json，方便阅读
+type IndentedJsonRenderer struct{}
+
+func (IndentedJsonRenderer) ContentType() string {
+	return "application/json; charset=UTF-8"
+}
+
+func (IndentedJsonRenderer) Render(c *Context, data interface{}) ([]byte, error) {
+	return json.MarshalIndent(data, "", "    ")
+}
+
+// PureJsonRenderer 输出json，不转义<、>、&等html字符
+type PureJsonRenderer struct{}
+
+func (PureJsonRenderer) ContentType() string {
+	return "application/json; charset=UTF-8"
+}
+
+func (PureJsonRenderer) Render(c *Context, data interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(data); err != nil {
+		return nil, err
+	}
+	// Encode会在末尾追加换行符
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// defaultJsonpCallbackParam JSONP回调函数名称的默认参数名
+const defaultJsonpCallbackParam = "callback"
+
+// JsonpRenderer 输出JSONP，回调函数名称从url参数CallbackParam中读取，CallbackParam为空时使用callback
+// 没有回调函数名称时输出json，回调函数名称只能包含字母、数字、_、$和.，不合法时返回400的*HTTPError
+type JsonpRenderer struct {
+	CallbackParam string
+}
+
+func (JsonpRenderer) ContentType() string {
+	return "application/javascript; charset=UTF-8"
+}
+
+func (r JsonpRenderer) Render(c *Context, data interface{}) ([]byte, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	param := r.CallbackParam
+	if param == "" {
+		param = defaultJsonpCallbackParam
+	}
+	callback := c.QueryString(param)
+	if callback == "" {
+		return b, nil
+	}
+	if !isJsonpCallback(callback) {
+		// 回调函数名称来自客户端，不合法时是客户端的错误
+		return nil, NewHTTPError(http.StatusBadRequest, "JSONP回调函数名称不合法")
+	}
+	// 回调函数名称前的注释用于防止Rosetta Flash攻击
+	buf := make([]byte, 0, len(callback)+len(b)+8)
+	buf = append(buf, "/**/"...)
+	buf = append(buf, callback...)
+	buf = append(buf, '(')
+	buf = append(buf, b...)
+	buf = append(buf, ");"...)
+	return buf, nil
+}
+
+func isJsonpCallback(callback string) bool {
+	for _, r := range callback {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '$', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// XmlRenderer 输出xml
+type XmlRenderer struct{}
+
+func (XmlRenderer) ContentType() string {
+	return "application/xml; charset=UTF-8"
+}
+
+func (XmlRenderer) Render(c *Context, data interface{}) ([]byte, error) {
+	return xml.Marshal(data)
+}
+
+// YamlRenderer 输出yaml
+type YamlRenderer struct{}
+
+func (YamlRenderer) ContentType() string {
+	return "application/x-yaml; charset=UTF-8"
+}
+
+func (YamlRenderer) Render(c *Context, data interface{}) ([]byte, error) {
+	return yaml.Marshal(data)
+}
+
+// ProtoMarshaler 可以编码为protobuf的数据，gogo/protobuf生成的message实现了此接口
+// 使用google.golang.org/protobuf时，可以注册调用proto.Marshal的Renderer替换ProtoBufRenderer
+type ProtoMarshaler interface {
+	Marshal() ([]byte, error)
+}
+
+// ProtoBufRenderer 输出protobuf，data必须实现ProtoMarshaler
+type ProtoBufRenderer struct{}
+
+func (ProtoBufRenderer) ContentType() string {
+	return "application/x-protobuf"
+}
+
+func (ProtoBufRenderer) Render(c *Context, data interface{}) ([]byte, error) {
+	m, ok := data.(ProtoMarshaler)
+	if !ok {
+		return nil, fmt.Errorf("data没有实现ProtoMarshaler type:%T", data)
+	}
+	return m.Marshal()
+}
+
+// renderers 按MIME类型注册的Renderer，mimeTypes是注册的顺序，Accept为*/*时使用第一个
+type renderers struct {
+	mimeTypes []string
+	m         map[string]Renderer
+}
+
+func newDefaultRenderers() *renderers {
+	rs := &renderers{m: make(map[string]Renderer)}
+	rs.register("application/json", JsonRenderer{})
+	rs.register("application/xml", XmlRenderer{})
+	rs.register("text/xml", XmlRenderer{})
+	rs.register("application/x-yaml", YamlRenderer{})
+	rs.register("application/yaml", YamlRenderer{})
+	rs.register("text/yaml", YamlRenderer{})
+	rs.register("application/x-protobuf", ProtoBufRenderer{})
+	rs.register("application/protobuf", ProtoBufRenderer{})
+	return rs
+}
+
+// defaultRenderers 没有Application时使用的Renderer
+var defaultRenderers = newDefaultRenderers()
+
+func (rs *renderers) register(mimeType string, r Renderer) {
+	if _, ok := rs.m[mimeType]; !ok {
+		rs.mimeTypes = append(rs.mimeTypes, mimeType)
+	}
+	rs.m[mimeType] = r
+}
+
+// negotiate 按Accept的优先级返回所有可以接受的Renderer，Accept为空时返回所有Renderer，第一个是json
+// 同一个media range匹配多个MIME类型时按注册的顺序返回
+// MIME类型的优先级由匹配的最具体的media range决定，所以*/*, application/json;q=0时不会返回json
+func (rs *renderers) negotiate(accept string) []Renderer {
+	if strings.TrimSpace(accept) == "" {
+		accept = "*/*"
+	}
+	ranges := parseAccept(accept)
+	var candidates []Renderer
+	added := make(map[string]bool, len(rs.mimeTypes))
+	for _, r := range ranges {
+		if r.q <= 0 {
+			// q为0的media range只用于排除，排在最后
+			break
+		}
+		for _, mimeType := range rs.mimeTypes {
+			if added[mimeType] || !r.match(mimeType) || mostSpecificRange(ranges, mimeType) != r {
+				continue
+			}
+			added[mimeType] = true
+			candidates = append(candidates, rs.m[mimeType])
+		}
+	}
+	return candidates
+}
+
+// mediaRange Accept中的一项，value已经转换为小写
+type mediaRange struct {
+	value string
+	q     float64
+}
+
+func (r mediaRange) match(mimeType string) bool {
+	if r.value == "*/*" || r.value == mimeType {
+		return true
+	}
+	return strings.HasSuffix(r.value, "/*") && strings.HasPrefix(mimeType, strings.TrimSuffix(r.value, "*"))
+}
+
+// specificity */*为0，text/*为1，具体的MIME类型为2
+func (r mediaRange) specificity() int {
+	switch {
+	case r.value == "*/*":
+		return 0
+	case strings.HasSuffix(r.value, "/*"):
+		return 1
+	}
+	return 2
+}
+
+// mostSpecificRange 返回匹配mimeType的最具体的media range，具体程度相同时返回q最大的
+func mostSpecificRange(ranges []mediaRange, mimeType string) mediaRange {
+	best, found := mediaRange{}, false
+	for _, r := range ranges {
+		if r.match(mimeType) && (!found || r.specificity() > best.specificity()) {
+			best, found = r, true
+		}
+	}
+	return best
+}
+
+// parseAccept 解析Accept头，按q值从大到小返回media range，q为0的排在最后，用于排除对应的MIME类型
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		value := strings.ToLower(strings.TrimSpace(params[0]))
+		if value == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range params[1:] {
+			kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if len(kv) == 2 && strings.TrimSpace(kv[0]) == "q" {
+				if f, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 64); err == nil {
+					q = f
+				}
+			}
+		}
+		ranges = append(ranges, mediaRange{value: value, q: q})
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+	return ranges
+}
+
+// RegisterRenderer 注册mimeType对应的Renderer，Negotiate根据请求的Accept选择Renderer
+// 默认注册了json、xml、yaml以及protobuf，Accept为空或者*/*时使用json
+// 重复注册同一mimeType时替换之前的Renderer，比如使用带缩进的json:
+//  app.RegisterRenderer("application/json", gwf.IndentedJsonRenderer{})
+//  app.RegisterRenderer("application/javascript", gwf.JsonpRenderer{})
+func (app *Application) RegisterRenderer(mimeType string, r Renderer) {
+	if r == nil {
+		panic("renderer不能是nil")
+	}
+	mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+	if mimeType == "" || strings.Contains(mimeType, "*") {
+		panic("mimeType不合法 mimeType:" + mimeType)
+	}
+	if app.renderers == nil {
+		app.renderers = newDefaultRenderers()
+	}
+	app.renderers.register(mimeType, r)
+}
+
+func (c *Context) renderers() *renderers {
+	if c.app == nil || c.app.renderers == nil {
+		return defaultRenderers
+	}
+	return c.app.renderers
+}
+
+// ErrNotAcceptable 没有Renderer可以输出请求的Accept
+var ErrNotAcceptable = errors.New("没有可以输出Accept的Renderer")
+
+// Negotiate 根据请求的Accept选择注册的Renderer输出data
+// 按Accept的优先级依次尝试，Renderer不能编码data时尝试下一个，比如xml不支持map、protobuf要求data实现ProtoMarshaler，
+// 没有匹配的Renderer或者都不能编码data时响应406，Accept由客户端控制，所以编码失败不会panic
+// Renderer返回*HTTPError时是客户端的错误，比如JSONP回调函数名称不合法，直接响应HTTPError.Code
+// 响应随Accept变化，所以会添加Vary: Accept响应头，避免缓存和CDN把一种格式的响应返回给其他客户端
+// 调用方需要自己使用return控制程序流程
+//  c.Negotiate(http.StatusOK, article)
+func (c *Context) Negotiate(code int, data interface{}) {
+	c.Writer.Header().Add("Vary", "Accept")
+	for _, r := range c.renderers().negotiate(c.Request.Header.Get("Accept")) {
+		b, err := r.Render(c, data)
+		if err != nil {
+			if c.renderClientError(err) {
+				return
+			}
+			if c.app != nil {
+				c.app.Logger.Printf("Renderer编码失败 url:%s content type:%s err:%s", c.Request.URL.Path, r.ContentType(), err)
+			}
+			continue
+		}
+		c.Writer.Header().Set("Content-Type", r.ContentType())
+		c.Bytes(code, b)
+		return
+	}
+	c.String(http.StatusNotAcceptable, ErrNotAcceptable.Error())
+}
+
+// RenderWith 使用r输出data，编码失败时panic，根据Accept选择Renderer时请使用Negotiate
+// Renderer返回*HTTPError时是客户端的错误，响应HTTPError.Code，不会panic
+// 调用方需要自己使用return控制程序流程
+func (c *Context) RenderWith(code int, r Renderer, data interface{}) {
+	b, err := r.Render(c, data)
+	if err != nil {
+		if c.renderClientError(err) {
+			return
+		}
+		panic(fmt.Sprintf("错误 err:%s", err))
+	}
+	c.Writer.Header().Set("Content-Type", r.ContentType())
+	c.Bytes(code, b)
+}
+
+// renderClientError err是*HTTPError时输出错误信息并返回true
+func (c *Context) renderClientError(err error) bool {
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		return false
+	}
+	if c.app != nil {
+		c.app.Logger.Printf("Renderer编码失败 url:%s err:%s", c.Request.URL.Path, err)
+	}
+	c.String(httpErr.Code, httpErr.Message)
+	return true
+}
+
+// IndentedJson 将data输出为带缩进的json
+func (c *Context) IndentedJson(code int, data interface{}) {
+	c.RenderWith(code, IndentedJsonRenderer{}, data)
+}
+
+// PureJson 将data输出为json，不转义html字符
+func (c *Context) PureJson(code int, data interface{}) {
+	c.RenderWith(code, PureJsonRenderer{}, data)
+}
+
+// Jsonp 将data输出为JSONP，回调函数名称从url参数callback中读取，不合法时响应400
+func (c *Context) Jsonp(code int, data interface{}) {
+	c.RenderWith(code, JsonpRenderer{}, data)
+}
+
+// Yaml 将data输出为yaml
+func (c *Context) Yaml(code int, data interface{}) {
+	c.RenderWith(code, YamlRenderer{}, data)
+}
+
+// ProtoBuf 将data输出为protobuf，data必须实现ProtoMarshaler
+func (c *Context) ProtoBuf(code int, data interface{}) {
+	c.RenderWith(code, ProtoBufRenderer{}, data)
+}
@@ -0,0 +1,129 @@
+package gwf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type renderArticle struct {
+	Title string `json:"title" xml:"title" yaml:"title"`
+}
+
+type protoArticle struct {
+	Title string
+}
+
+func (a *protoArticle) Marshal() ([]byte, error) {
+	return append([]byte{0x0a, byte(len(a.Title))}, a.Title...), nil
+}
+
+func TestParseAccept(t *testing.T) {
+	assert.Equal(t, []mediaRange{{"application/xml", 1}, {"application/json", 0.9}, {"*/*", 0.1}, {"text/html", 0}},
+		parseAccept("application/json;q=0.9, application/xml, */*;q=0.1, text/html;q=0"))
+	assert.Empty(t, parseAccept(""))
+}
+
+func TestNegotiate(t *testing.T) {
+	app := newTestApplication()
+	app.GET("/article", func(c *Context) {
+		c.Negotiate(http.StatusOK, &renderArticle{Title: "go"})
+	})
+	app.GET("/proto", func(c *Context) {
+		c.Negotiate(http.StatusOK, &protoArticle{Title: "go"})
+	})
+	app.GET("/map", func(c *Context) {
+		c.Negotiate(http.StatusOK, map[string]string{"title": "go"})
+	})
+
+	tests := []struct {
+		path        string
+		accept      string
+		code        int
+		contentType string
+		body        string
+	}{
+		{"/article", "", http.StatusOK, "application/json; charset=UTF-8", `{"title":"go"}`},
+		{"/article", "text/html,*/*;q=0.8", http.StatusOK, "application/json; charset=UTF-8", `{"title":"go"}`},
+		{"/article", "application/json;q=0.5, application/xml", http.StatusOK, "application/xml; charset=UTF-8", `<renderArticle><title>go</title></renderArticle>`},
+		{"/article", "text/*", http.StatusOK, "application/xml; charset=UTF-8", `<renderArticle><title>go</title></renderArticle>`},
+		{"/article", "application/x-yaml", http.StatusOK, "application/x-yaml; charset=UTF-8", "title: go\n"},
+		// q为0的MIME类型被排除，即使*/*可以匹配
+		{"/article", "*/*, application/json;q=0", http.StatusOK, "application/xml; charset=UTF-8", `<renderArticle><title>go</title></renderArticle>`},
+		{"/article", "application/*;q=0, */*", http.StatusOK, "application/xml; charset=UTF-8", `<renderArticle><title>go</title></renderArticle>`},
+		{"/article", "application/*;q=0, application/json", http.StatusOK, "application/json; charset=UTF-8", `{"title":"go"}`},
+		{"/article", "text/html", http.StatusNotAcceptable, "text/plain; charset=UTF-8", ErrNotAcceptable.Error()},
+		{"/proto", "application/x-protobuf", http.StatusOK, "application/x-protobuf", "\x0a\x02go"},
+		// Renderer不能编码data时尝试下一个，都不能编码时响应406
+		{"/map", "application/xml", http.StatusNotAcceptable, "text/plain; charset=UTF-8", ErrNotAcceptable.Error()},
+		{"/map", "application/xml, application/json;q=0.5", http.StatusOK, "application/json; charset=UTF-8", `{"title":"go"}`},
+		{"/map", "text/xml, */*;q=0.1", http.StatusOK, "application/json; charset=UTF-8", `{"title":"go"}`},
+		{"/article", "application/x-protobuf", http.StatusNotAcceptable, "text/plain; charset=UTF-8", ErrNotAcceptable.Error()},
+		{"/article", "application/x-protobuf, application/xml;q=0.9", http.StatusOK, "application/xml; charset=UTF-8", `<renderArticle><title>go</title></renderArticle>`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", tt.path, nil)
+		r.Header.Set("Accept", tt.accept)
+		app.ServeHTTP(w, r)
+		assert.Equal(t, tt.code, w.Code, tt.accept)
+		assert.Equal(t, tt.contentType, w.Header().Get("Content-Type"), tt.accept)
+		assert.Equal(t, "Accept", w.Header().Get("Vary"), tt.accept)
+		assert.Equal(t, tt.body, w.Body.String(), tt.accept)
+	}
+
+	app.RegisterRenderer("application/json", IndentedJsonRenderer{})
+	app.RegisterRenderer("application/javascript", JsonpRenderer{})
+	for accept, body := range map[string]string{
+		"application/json":       "{\n    \"title\": \"go\"\n}",
+		"application/javascript": `/**/show({"title":"go"});`,
+	} {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/article?callback=show", nil)
+		r.Header.Set("Accept", accept)
+		app.ServeHTTP(w, r)
+		assert.Equal(t, body, w.Body.String(), accept)
+	}
+
+	// 回调函数名称不合法是客户端的错误，响应400，不能panic
+	app.GET("/jsonp", func(c *Context) {
+		c.Jsonp(http.StatusOK, &renderArticle{Title: "go"})
+	})
+	for _, path := range []string{"/jsonp", "/article"} {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", path+"?callback=alert(1)", nil)
+		r.Header.Set("Accept", "application/javascript")
+		assert.NotPanics(t, func() { app.ServeHTTP(w, r) }, path)
+		assert.Equal(t, http.StatusBadRequest, w.Code, path)
+		assert.Equal(t, "JSONP回调函数名称不合法", w.Body.String(), path)
+	}
+}
+
+func TestRenderers(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/article?callback=alert(1)", nil)
+	c := newCtx(nil, r)
+
+	b, err := PureJsonRenderer{}.Render(c, map[string]string{"html": "<b>go</b>"})
+	assert.Nil(t, err)
+	assert.Equal(t, `{"html":"<b>go</b>"}`, string(b))
+
+	b, err = JsonRenderer{}.Render(c, map[string]string{"html": "<b>go</b>"})
+	assert.Nil(t, err)
+	assert.Equal(t, `{"html":"\u003cb\u003ego\u003c/b\u003e"}`, string(b))
+
+	_, err = JsonpRenderer{}.Render(c, 1)
+	httpErr, ok := err.(*HTTPError)
+	if assert.True(t, ok) {
+		assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+	}
+	b, err = JsonpRenderer{CallbackParam: "cb"}.Render(c, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "1", string(b))
+
+	_, err = ProtoBufRenderer{}.Render(c, &renderArticle{})
+	assert.NotNil(t, err)
+
+	assert.Panics(t, func() { newTestApplication().RegisterRenderer("text/*", JsonRenderer{}) })
+}
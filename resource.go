@@ -0,0 +1,136 @@
+package gwf
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// resourceAction 资源路由中的一个action
+type resourceAction struct {
+	// name controller中的方法名称
+	name    string
+	methods []string
+	// member 为true时路由包含资源id，比如/articles/:id
+	member bool
+	suffix string
+}
+
+// resourceActions 资源路由的action，方法签名与action方法相同
+var resourceActions = []resourceAction{
+	{name: "Index", methods: []string{http.MethodGet}},
+	{name: "New", methods: []string{http.MethodGet}, suffix: "/new"},
+	{name: "Create", methods: []string{http.MethodPost}},
+	{name: "Show", methods: []string{http.MethodGet}, member: true},
+	{name: "Edit", methods: []string{http.MethodGet}, member: true, suffix: "/edit"},
+	{name: "Update", methods: []string{http.MethodPut, http.MethodPatch}, member: true},
+	{name: "Destroy", methods: []string{http.MethodDelete}, member: true},
+}
+
+const defaultResourceParam = "id"
+
+type resourceOptions struct {
+	only   map[string]bool
+	except map[string]bool
+	param  string
+}
+
+// ResourceOption Resource的选项
+type ResourceOption func(*resourceOptions)
+
+// ResourceOnly 只注册指定的action，比如ResourceOnly("Index", "Show")
+func ResourceOnly(actions ...string) ResourceOption {
+	return func(o *resourceOptions) {
+		o.only = make(map[string]bool, len(actions))
+		for _, action := range actions {
+			o.only[action] = true
+		}
+	}
+}
+
+// ResourceExcept 不注册指定的action，比如ResourceExcept("New", "Edit")
+func ResourceExcept(actions ...string) ResourceOption {
+	return func(o *resourceOptions) {
+		o.except = make(map[string]bool, len(actions))
+		for _, action := range actions {
+			o.except[action] = true
+		}
+	}
+}
+
+// ResourceParam 设置资源id的参数名称，默认是id
+// 嵌套的资源需要使用不同的参数名称，否则会与上级资源的参数名称重复
+func ResourceParam(name string) ResourceOption {
+	return func(o *resourceOptions) {
+		o.param = name
+	}
+}
+
+// Resource 按照RESTFUL的约定注册资源路由，controller中定义了的方法才会注册:
+//  Index   GET        /articles
+//  New     GET        /articles/new
+//  Create  POST       /articles
+//  Show    GET        /articles/:id
+//  Edit    GET        /articles/:id/edit
+//  Update  PUT/PATCH  /articles/:id
+//  Destroy DELETE     /articles/:id
+// controller需要满足RegisterDynamicRouter对controller的要求，方法签名与action方法相同，也可以使用typed action的签名
+// Init、BeforeAction、AfterAction以及ActionFilters与动态路由的用法相同，ActionFilters的key是上面的方法名称
+// 返回以/articles/:id为前缀的路由组，可以用来注册嵌套的资源:
+//  articles := app.Resource("/articles", &ArticleController{}, gwf.ResourceExcept("New", "Edit"))
+//  // /articles/:id/comments/:comment_id
+//  articles.Resource("/comments", &CommentController{}, gwf.ResourceParam("comment_id"))
+func (rg *RouterGroup) Resource(path string, controller interface{}, opts ...ResourceOption) *RouterGroup {
+	if controller == nil {
+		panic("controller不能是nil")
+	}
+	if !isController(controller) {
+		panic("controller定义不满足规范")
+	}
+	if path == "" || path[0] != '/' {
+		panic("resource path must begin with '/'")
+	}
+	path = strings.TrimRight(path, "/")
+
+	options := resourceOptions{param: defaultResourceParam}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.param == "" || strings.ContainsAny(options.param, "/:*") {
+		panic("资源参数名称不合法 param:" + options.param)
+	}
+	memberPath := path + "/:" + options.param
+
+	controllerPointerType := reflect.TypeOf(controller)
+	for _, action := range resourceActions {
+		if options.only != nil && !options.only[action.name] {
+			continue
+		}
+		if options.except[action.name] {
+			continue
+		}
+		method, ok := controllerPointerType.MethodByName(action.name)
+		if !ok || !isActionMethodType(method.Type) {
+			continue
+		}
+
+		actionPath := path
+		if action.member {
+			actionPath = memberPath
+		}
+		actionPath += action.suffix
+		if actionPath == "" {
+			actionPath = "/"
+		}
+
+		invoker := newActionInvoker(method.Func.Interface())
+		handlers := append(actionFilters(controller, action.name), invoker.invoke)
+		for _, m := range action.methods {
+			routeInfo := rg.addRoute(m, actionPath, handlers...)
+			routeInfo.handlerName = nameOfFunction(method.Func.Interface())
+			routeInfo.invoker = invoker
+		}
+	}
+
+	return rg.Group(memberPath)
+}
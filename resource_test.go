@@ -0,0 +1,91 @@
+package gwf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type articleController struct {
+	*Controller
+}
+
+func (controller *articleController) Init() {}
+
+func (controller *articleController) Index(c *Context) {
+	c.String(http.StatusOK, "index")
+}
+
+func (controller *articleController) Create(c *Context) {
+	c.String(http.StatusOK, "create")
+}
+
+func (controller *articleController) Show(c *Context) {
+	c.String(http.StatusOK, "show "+c.PathParam("id"))
+}
+
+func (controller *articleController) Update(c *Context) {
+	c.String(http.StatusOK, "update "+c.PathParam("id"))
+}
+
+func (controller *articleController) Destroy(c *Context) {
+	c.String(http.StatusOK, "destroy "+c.PathParam("id"))
+}
+
+type commentController struct {
+	*Controller
+}
+
+func (controller *commentController) Init() {}
+
+func (controller *commentController) Index(c *Context) {
+	c.String(http.StatusOK, "comments of "+c.PathParam("id"))
+}
+
+func (controller *commentController) Show(c *Context) {
+	c.String(http.StatusOK, "comment "+c.PathParam("comment_id")+" of "+c.PathParam("id"))
+}
+
+func TestResource(t *testing.T) {
+	app := newTestApplication()
+	articles := app.Resource("/articles", &articleController{}, ResourceExcept("Destroy"))
+	articles.Resource("/comments", &commentController{}, ResourceParam("comment_id"), ResourceOnly("Show"))
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+		body   string
+	}{
+		{"GET", "/articles", http.StatusOK, "index"},
+		{"GET", "/articles/12", http.StatusOK, "show 12"},
+		{"PUT", "/articles/12", http.StatusOK, "update 12"},
+		{"PATCH", "/articles/12", http.StatusOK, "update 12"},
+		{"DELETE", "/articles/12", http.StatusNotFound, ""},
+		{"GET", "/articles/new", http.StatusOK, "show new"},
+		{"GET", "/articles/12/comments/3", http.StatusOK, "comment 3 of 12"},
+		{"GET", "/articles/12/comments", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		assert.Equal(t, tt.code, w.Code, tt.method+" "+tt.path)
+		if tt.body != "" {
+			assert.Equal(t, tt.body, w.Body.String(), tt.method+" "+tt.path)
+		}
+	}
+
+	_, ok := app.RouterGroup.Routes["POST"]["/articles"]
+	assert.True(t, ok)
+	_, ok = app.RouterGroup.Routes["GET"]["/articles/:id/edit"]
+	assert.False(t, ok)
+
+	assert.Panics(t, func() {
+		app.Resource("/posts", &articleController{}, ResourceParam("a/b"))
+	})
+	assert.Panics(t, func() {
+		articles.Resource("/tags", &commentController{})
+	})
+}
@@ -1,7 +1,9 @@
 package gwf
 
 import (
+	"bufio"
 	"io"
+	"net"
 	"net/http"
 )
 
@@ -21,6 +23,35 @@ type ResponseHeaderHandler func(http.Header)
 // ResponseBodyHandler 响应body的装饰器handler
 type ResponseBodyHandler func([]byte) []byte
 
+// ResponseWriter Context.Writer的类型，包装了请求的http.ResponseWriter
+// 只有底层的ResponseWriter支持时，才实现http.Hijacker、http.Pusher以及http.CloseNotifier:
+//  if hj, ok := c.Writer.(http.Hijacker); ok {
+//  	conn, rw, err := hj.Hijack()
+//  }
+type ResponseWriter interface {
+	http.ResponseWriter
+	http.Flusher
+	io.StringWriter
+
+	// WriteHeaderNow 将status写入响应头
+	WriteHeaderNow()
+	// Written 已写入响应返回true
+	Written() bool
+	// Status 返回当前响应状态码
+	Status() int
+	// Size 返回当前响应字节数
+	Size() int
+	// Unwrap 返回底层的http.ResponseWriter
+	Unwrap() http.ResponseWriter
+
+	// SetResponseStatusHandler 设置响应状态的装饰器，需要在写入响应之前调用
+	SetResponseStatusHandler(h ResponseStatusHandler)
+	// SetResponseHeaderHandler 设置响应头的装饰器，需要在写入响应之前调用
+	SetResponseHeaderHandler(h ResponseHeaderHandler)
+	// SetResponseBodyHandler 设置响应body的装饰器，Write和WriteString都会经过此装饰器
+	SetResponseBodyHandler(h ResponseBodyHandler)
+}
+
 type responseWriter struct {
 	http.ResponseWriter
 	size   int
@@ -31,10 +62,10 @@ type responseWriter struct {
 	ResponseBodyHandler   ResponseBodyHandler
 }
 
-// NewResponseWriter 初始化
+// NewResponseWriter 初始化，返回值支持的可选接口与w相同
 func NewResponseWriter(w http.ResponseWriter, respStatusHandler ResponseStatusHandler, respHeaderHandler ResponseHeaderHandler,
-	respBodyHandler ResponseBodyHandler) *responseWriter {
-	return &responseWriter{
+	respBodyHandler ResponseBodyHandler) ResponseWriter {
+	rw := &responseWriter{
 		ResponseWriter:        w,
 		size:                  NoWritten,
 		status:                DefaultStatus,
@@ -42,6 +73,17 @@ func NewResponseWriter(w http.ResponseWriter, respStatusHandler ResponseStatusHa
 		ResponseHeaderHandler: respHeaderHandler,
 		ResponseBodyHandler:   respBodyHandler,
 	}
+	return wrapResponseWriter(rw, writerCapabilities(w))
+}
+
+// reset 重置为w的初始状态，用于复用responseWriter
+func (w *responseWriter) reset(rw http.ResponseWriter) {
+	w.ResponseWriter = rw
+	w.size = NoWritten
+	w.status = DefaultStatus
+	w.ResponseStatusHandler = nil
+	w.ResponseHeaderHandler = nil
+	w.ResponseBodyHandler = nil
 }
 
 // Written 已写入响应返回true
@@ -83,14 +125,36 @@ func (w *responseWriter) Write(data []byte) (n int, err error) {
 	return
 }
 
-// WriteString 写入响应数据
+// WriteString 写入响应数据，与Write相同会经过ResponseBodyHandler
 func (w *responseWriter) WriteString(s string) (n int, err error) {
+	if w.ResponseBodyHandler != nil {
+		return w.Write([]byte(s))
+	}
 	w.WriteHeaderNow()
-	n, err = io.WriteString(w.ResponseWriter, s)
+	if sw, ok := w.ResponseWriter.(io.StringWriter); ok {
+		n, err = sw.WriteString(s)
+	} else {
+		n, err = w.ResponseWriter.Write([]byte(s))
+	}
 	w.size += n
 	return
 }
 
+// SetResponseStatusHandler 设置响应状态的装饰器，需要在写入响应之前调用
+func (w *responseWriter) SetResponseStatusHandler(h ResponseStatusHandler) {
+	w.ResponseStatusHandler = h
+}
+
+// SetResponseHeaderHandler 设置响应头的装饰器，需要在写入响应之前调用
+func (w *responseWriter) SetResponseHeaderHandler(h ResponseHeaderHandler) {
+	w.ResponseHeaderHandler = h
+}
+
+// SetResponseBodyHandler 设置响应body的装饰器，Write和WriteString都会经过此装饰器
+func (w *responseWriter) SetResponseBodyHandler(h ResponseBodyHandler) {
+	w.ResponseBodyHandler = h
+}
+
 // Status 返回当前响应状态码
 func (w *responseWriter) Status() int {
 	return w.status
@@ -100,3 +164,149 @@ func (w *responseWriter) Status() int {
 func (w *responseWriter) Size() int {
 	return w.size
 }
+
+// Flush 实现http.Flusher，将已写入的数据立即发送给客户端，用于流式响应
+// 还没有写入响应头时先写入响应头
+func (w *responseWriter) Flush() {
+	w.WriteHeaderNow()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap 返回底层的ResponseWriter，http.ResponseController通过Unwrap访问底层的ResponseWriter
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// 底层ResponseWriter支持的可选接口，用于选择包装类型
+const (
+	supportHijack = 1 << iota
+	supportPush
+	supportCloseNotify
+)
+
+// writerCapabilities 返回w支持的可选接口
+func writerCapabilities(w http.ResponseWriter) int {
+	capabilities := 0
+	if _, ok := w.(http.Hijacker); ok {
+		capabilities |= supportHijack
+	}
+	if _, ok := w.(http.Pusher); ok {
+		capabilities |= supportPush
+	}
+	if _, ok := w.(http.CloseNotifier); ok {
+		capabilities |= supportCloseNotify
+	}
+	return capabilities
+}
+
+// wrapResponseWriter 根据capabilities选择包装类型，只有底层ResponseWriter支持时，
+// 返回值才实现http.Hijacker、http.Pusher以及http.CloseNotifier，websocket等库可以直接使用类型断言判断
+func wrapResponseWriter(w *responseWriter, capabilities int) ResponseWriter {
+	h, p, n := hijacker{w}, pusher{w}, closeNotifier{w}
+	switch capabilities {
+	case supportHijack:
+		return &struct {
+			*responseWriter
+			hijacker
+		}{w, h}
+	case supportPush:
+		return &struct {
+			*responseWriter
+			pusher
+		}{w, p}
+	case supportCloseNotify:
+		return &struct {
+			*responseWriter
+			closeNotifier
+		}{w, n}
+	case supportHijack | supportPush:
+		return &struct {
+			*responseWriter
+			hijacker
+			pusher
+		}{w, h, p}
+	case supportHijack | supportCloseNotify:
+		return &struct {
+			*responseWriter
+			hijacker
+			closeNotifier
+		}{w, h, n}
+	case supportPush | supportCloseNotify:
+		return &struct {
+			*responseWriter
+			pusher
+			closeNotifier
+		}{w, p, n}
+	case supportHijack | supportPush | supportCloseNotify:
+		return &struct {
+			*responseWriter
+			hijacker
+			pusher
+			closeNotifier
+		}{w, h, p, n}
+	}
+	return w
+}
+
+// hijacker 转发Hijack，只在底层ResponseWriter实现了http.Hijacker时使用
+type hijacker struct {
+	w *responseWriter
+}
+
+// Hijack 实现http.Hijacker，用于websocket等需要接管连接的场景，接管之后不能再写入响应
+func (h hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := h.w.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil && !h.w.Written() {
+		// 连接已经被接管，避免之后再写入响应头
+		h.w.size = 0
+	}
+	return conn, rw, err
+}
+
+// pusher 转发Push，只在底层ResponseWriter实现了http.Pusher时使用
+type pusher struct {
+	w *responseWriter
+}
+
+// Push 实现http.Pusher，用于HTTP/2服务端推送
+func (p pusher) Push(target string, opts *http.PushOptions) error {
+	return p.w.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+// closeNotifier 转发CloseNotify，只在底层ResponseWriter实现了http.CloseNotifier时使用
+type closeNotifier struct {
+	w *responseWriter
+}
+
+// CloseNotify 实现http.CloseNotifier
+//
+// Deprecated: 请使用Context的Done
+func (n closeNotifier) CloseNotify() <-chan bool {
+	return n.w.ResponseWriter.(http.CloseNotifier).CloseNotify()
+}
+
+// discardBodyWriter 只写入响应头，丢弃响应body，用于自动响应的HEAD请求
+// 包装在请求的http.ResponseWriter上，handler替换c.Writer时(比如使用c.Writer.Unwrap()创建新的ResponseWriter)同样丢弃body
+type discardBodyWriter struct {
+	http.ResponseWriter
+}
+
+func (w discardBodyWriter) Write(data []byte) (int, error) {
+	return len(data), nil
+}
+
+func (w discardBodyWriter) WriteString(s string) (int, error) {
+	return len(s), nil
+}
+
+func (w discardBodyWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w discardBodyWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,146 @@
+package gwf
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type pushRecorder struct {
+	*httptest.ResponseRecorder
+	pushed []string
+}
+
+func (r *pushRecorder) Push(target string, opts *http.PushOptions) error {
+	r.pushed = append(r.pushed, target)
+	return nil
+}
+
+func TestResponseWriterBodyHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := NewResponseWriter(rec, nil, nil, ResponseBodyHandler(bytes.ToUpper))
+	w.Write([]byte("hello "))
+	w.WriteString("world")
+	assert.Equal(t, "HELLO WORLD", rec.Body.String())
+	assert.Equal(t, 11, w.Size())
+
+	// 中间件通过Set方法为每个请求设置装饰器
+	app := newTestApplication()
+	app.AddMiddleware(func(c *Context) {
+		c.Writer.SetResponseStatusHandler(func(code int) int { return code + 1 })
+		c.Writer.SetResponseHeaderHandler(func(h http.Header) { h.Set("X-Decorated", "1") })
+		c.Writer.SetResponseBodyHandler(bytes.ToUpper)
+		c.Next()
+	})
+	app.GET("/hello", func(c *Context) {
+		c.String(http.StatusOK, "hello")
+	})
+	// handler替换c.Writer之后，自动响应的HEAD请求同样丢弃body
+	app.GET("/swap", func(c *Context) {
+		c.Writer = NewResponseWriter(c.Writer.Unwrap(), nil, nil, nil)
+		c.String(http.StatusOK, "swapped")
+	})
+	rec = httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest("GET", "/hello", nil))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("X-Decorated"))
+	assert.Equal(t, "HELLO", rec.Body.String())
+
+	for method, body := range map[string]string{"GET": "swapped", "HEAD": ""} {
+		rec = httptest.NewRecorder()
+		app.ServeHTTP(rec, httptest.NewRequest(method, "/swap", nil))
+		assert.Equal(t, body, rec.Body.String(), method)
+	}
+}
+
+func TestResponseWriterInterfaces(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := NewResponseWriter(rec, nil, nil, nil)
+	var _ http.Flusher = w
+	assert.Equal(t, http.ResponseWriter(rec), w.Unwrap())
+
+	// httptest.ResponseRecorder不支持Hijack、Push以及CloseNotify
+	_, ok := w.(http.Hijacker)
+	assert.False(t, ok)
+	_, ok = w.(http.Pusher)
+	assert.False(t, ok)
+	_, ok = w.(http.CloseNotifier)
+	assert.False(t, ok)
+
+	pusher := &pushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	w = NewResponseWriter(pusher, nil, nil, nil)
+	_, ok = w.(http.Hijacker)
+	assert.False(t, ok)
+	if p, ok := w.(http.Pusher); assert.True(t, ok) {
+		assert.Nil(t, p.Push("/app.js", nil))
+		assert.Equal(t, []string{"/app.js"}, pusher.pushed)
+	}
+
+	// 复用Context时按底层ResponseWriter重新选择包装类型
+	c := newCtx(nil, httptest.NewRequest("GET", "/", nil))
+	c.resetWriter(pusher)
+	_, ok = c.Writer.(http.Pusher)
+	assert.True(t, ok)
+	c.resetWriter(rec)
+	_, ok = c.Writer.(http.Pusher)
+	assert.False(t, ok)
+	assert.Equal(t, http.ResponseWriter(rec), c.Writer.Unwrap())
+}
+
+func TestResponseWriterHijack(t *testing.T) {
+	app := newTestApplication()
+	app.GET("/ws", func(c *Context) {
+		_, ok := c.Writer.(http.CloseNotifier)
+		assert.True(t, ok)
+		hj, ok := c.Writer.(http.Hijacker)
+		if !assert.True(t, ok) {
+			return
+		}
+		conn, rw, err := hj.Hijack()
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: echo\r\nConnection: Upgrade\r\n\r\n")
+		rw.Flush()
+		line, _ := rw.ReadString('\n')
+		rw.WriteString("echo:" + line)
+		rw.Flush()
+	})
+	app.GET("/chunked", func(c *Context) {
+		c.Writer.WriteString("part1")
+		c.Writer.Flush()
+		c.Writer.WriteString("part2")
+	})
+	server := httptest.NewServer(app)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer conn.Close()
+	conn.Write([]byte("GET /ws HTTP/1.1\r\nHost: gwf\r\nUpgrade: echo\r\nConnection: Upgrade\r\n\r\n"))
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if assert.Nil(t, err) {
+		assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	}
+	conn.Write([]byte("ping\n"))
+	line, _ := br.ReadString('\n')
+	assert.Equal(t, "echo:ping\n", line)
+
+	resp, err = http.Get(server.URL + "/chunked")
+	if assert.Nil(t, err) {
+		defer resp.Body.Close()
+		assert.Equal(t, []string{"chunked"}, resp.TransferEncoding)
+		body, _ := ioutil.ReadAll(resp.Body)
+		assert.Equal(t, "part1part2", string(body))
+	}
+}
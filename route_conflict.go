@@ -0,0 +1,209 @@
+package gwf
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// routerGroupFuncPrefix RouterGroup方法的函数名前缀，获取路由注册位置时跳过这些调用
+var routerGroupFuncPrefix = reflect.TypeOf(RouterGroup{}).PkgPath() + ".(*RouterGroup)."
+
+// registrationCaller 返回注册路由的代码位置，跳过RouterGroup内部的调用
+func registrationCaller() (file string, line int) {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, routerGroupFuncPrefix) && frame.File != "<autogenerated>" {
+			return frame.File, frame.Line
+		}
+		if !more {
+			return "", 0
+		}
+	}
+}
+
+// RouteConflict 两个路由组中可能匹配同一请求的两个路由
+// 请求会被先匹配的路由组处理，后者永远不会被执行
+type RouteConflict struct {
+	First  *RouteInfo
+	Second *RouteInfo
+}
+
+func (rc RouteConflict) String() string {
+	return fmt.Sprintf("%s 与 %s 冲突", rc.First.location(), rc.Second.location())
+}
+
+// RouteConflictError 路由冲突错误，包含所有冲突的路由
+type RouteConflictError struct {
+	Conflicts []RouteConflict
+	// DuplicateNames 在多个路由组中重复注册的路由名称
+	DuplicateNames []RouteConflict
+}
+
+func (e *RouteConflictError) Error() string {
+	var b strings.Builder
+	b.WriteString("路由冲突:")
+	for _, rc := range e.Conflicts {
+		b.WriteString("\n\t")
+		b.WriteString(rc.String())
+	}
+	for _, rc := range e.DuplicateNames {
+		b.WriteString("\n\t路由名称")
+		b.WriteString(rc.First.name)
+		b.WriteString("重复: ")
+		b.WriteString(rc.String())
+	}
+	return b.String()
+}
+
+// location 返回路由的描述，包含路由组名称以及注册的代码位置
+func (ri *RouteInfo) location() string {
+	s := fmt.Sprintf("%s %s (group:%s", ri.method, ri.path, ri.group.name)
+	if ri.file != "" {
+		s += fmt.Sprintf(" %s:%d", ri.file, ri.line)
+	}
+	return s + ")"
+}
+
+// CheckRoutes 检查所有路由组的路由表，路由组之间存在冲突时返回*RouteConflictError
+// 冲突是指不同路由组中可以匹配同一请求的路由，比如/user/:id和/user/new，
+// 以及在不同路由组中重复使用的路由名称
+// 绑定host的路由组优先于未绑定host的路由组匹配，只有两者都未绑定host或者绑定的host可能相同时才会检查冲突
+// Start会在启动服务之前调用此方法，存在冲突时直接panic
+func (app *Application) CheckRoutes() error {
+	groups := app.routerGroups()
+	conflictErr := &RouteConflictError{}
+	for i := 0; i < len(groups); i++ {
+		for j := i + 1; j < len(groups); j++ {
+			if sameRouteTable(groups[i], groups[j]) || !hostsOverlap(groups[i], groups[j]) {
+				continue
+			}
+			checkRouterGroupConflicts(groups[i], groups[j], conflictErr)
+		}
+	}
+	if len(conflictErr.Conflicts) > 0 || len(conflictErr.DuplicateNames) > 0 {
+		return conflictErr
+	}
+	return nil
+}
+
+// sameRouteTable 通过Group创建的子路由组与父路由组共享路由表，不需要相互检查
+func sameRouteTable(a, b *RouterGroup) bool {
+	if a == b {
+		return true
+	}
+	if a.Routes == nil || b.Routes == nil {
+		return false
+	}
+	return reflect.ValueOf(a.Routes).Pointer() == reflect.ValueOf(b.Routes).Pointer()
+}
+
+func hostsOverlap(a, b *RouterGroup) bool {
+	if a.host == nil || b.host == nil {
+		return a.host == b.host
+	}
+	return a.host.overlap(b.host)
+}
+
+func checkRouterGroupConflicts(a, b *RouterGroup, conflictErr *RouteConflictError) {
+	for _, method := range sortedMethods(a.Routes) {
+		others, ok := b.Routes[method]
+		if !ok {
+			continue
+		}
+		for _, first := range sortedRouteInfos(a.Routes[method]) {
+			firstSegments := patternSegments(first.path)
+			for _, second := range sortedRouteInfos(others) {
+				if patternsOverlap(firstSegments, patternSegments(second.path)) {
+					conflictErr.Conflicts = append(conflictErr.Conflicts, RouteConflict{First: first, Second: second})
+				}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(a.names))
+	for name := range a.names {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if second, ok := b.names[name]; ok {
+			conflictErr.DuplicateNames = append(conflictErr.DuplicateNames, RouteConflict{First: a.names[name], Second: second})
+		}
+	}
+}
+
+func sortedMethods(routes map[string]map[string]*RouteInfo) []string {
+	methods := make([]string, 0, len(routes))
+	for method := range routes {
+		methods = append(methods, method)
+	}
+	sort.Slice(methods, func(i, j int) bool {
+		if methodOrder(methods[i]) != methodOrder(methods[j]) {
+			return methodOrder(methods[i]) < methodOrder(methods[j])
+		}
+		return methods[i] < methods[j]
+	})
+	return methods
+}
+
+func sortedRouteInfos(routes map[string]*RouteInfo) []*RouteInfo {
+	paths := make([]string, 0, len(routes))
+	for path := range routes {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	routeInfos := make([]*RouteInfo, 0, len(paths))
+	for _, path := range paths {
+		routeInfos = append(routeInfos, routes[path])
+	}
+	return routeInfos
+}
+
+// patternSegments 将路由按'/'切分，/对应[""]，/user/:id对应["user", ":id"]
+func patternSegments(path string) []string {
+	return strings.Split(strings.TrimPrefix(path, "/"), "/")
+}
+
+// patternsOverlap 判断两个路由是否可以匹配同一个请求path
+//  静态片段只匹配相同的片段
+//  :name 匹配任意一段非空片段
+//  *name 匹配剩余的一段或多段片段(可以是空字符串)
+func patternsOverlap(a, b []string) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return len(a) == len(b)
+	}
+	if isCatchAllSegment(a[0]) || isCatchAllSegment(b[0]) {
+		return true
+	}
+	aParam, bParam := isParamSegment(a[0]), isParamSegment(b[0])
+	switch {
+	case aParam && bParam:
+	case aParam:
+		if b[0] == "" {
+			return false
+		}
+	case bParam:
+		if a[0] == "" {
+			return false
+		}
+	default:
+		if a[0] != b[0] {
+			return false
+		}
+	}
+	return patternsOverlap(a[1:], b[1:])
+}
+
+func isParamSegment(seg string) bool {
+	return strings.HasPrefix(seg, ":")
+}
+
+func isCatchAllSegment(seg string) bool {
+	return strings.HasPrefix(seg, "*")
+}
@@ -0,0 +1,56 @@
+package gwf
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatternsOverlap(t *testing.T) {
+	tests := []struct {
+		a, b    string
+		overlap bool
+	}{
+		{"/", "/", true},
+		{"/", "/:id", false},
+		{"/user", "/user", true},
+		{"/user", "/users", false},
+		{"/user/:id", "/user/new", true},
+		{"/user/:id", "/user/:name", true},
+		{"/user/:id", "/user/:id/profile", false},
+		{"/files/*filepath", "/files", false},
+		{"/files/*filepath", "/files/a/b", true},
+		{"/*all", "/", true},
+		{"/:a/x", "/y/:b", true},
+		{"/:a/x", "/y/z", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.overlap, patternsOverlap(patternSegments(tt.a), patternSegments(tt.b)), tt.a+" "+tt.b)
+		assert.Equal(t, tt.overlap, patternsOverlap(patternSegments(tt.b), patternSegments(tt.a)), tt.b+" "+tt.a)
+	}
+}
+
+func TestCheckRoutes(t *testing.T) {
+	app := newTestApplication()
+	app.GET("/user/:id", func(_ *Context) {}).Name("user")
+	app.POST("/user/new", func(_ *Context) {})
+	app.Group("/v1").GET("/ping", func(_ *Context) {})
+	assert.Nil(t, app.CheckRoutes())
+
+	rg := NewRouterGroup(app, "other")
+	rg.GET("/user/new", func(_ *Context) {})
+	rg.GET("/about", func(_ *Context) {}).Name("user")
+	app.AddRouterGroup(rg)
+
+	err := app.CheckRoutes()
+	if assert.NotNil(t, err) {
+		conflictErr := err.(*RouteConflictError)
+		assert.Equal(t, 1, len(conflictErr.Conflicts))
+		assert.Equal(t, "/user/:id", conflictErr.Conflicts[0].First.path)
+		assert.Equal(t, "/user/new", conflictErr.Conflicts[0].Second.path)
+		assert.Equal(t, 1, len(conflictErr.DuplicateNames))
+		assert.True(t, strings.HasSuffix(conflictErr.Conflicts[0].Second.file, "route_conflict_test.go"))
+		assert.Contains(t, err.Error(), "GET /user/new (group:other")
+	}
+}
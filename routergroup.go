@@ -1,9 +1,11 @@
 package gwf
 
 import (
+	"context"
 	"fmt"
 	"html/template"
 	"net/http"
+	"net/url"
 	"reflect"
 	"regexp"
 	"strings"
@@ -14,6 +16,17 @@ import (
 
 const AppDefaultRouterGroupName = "app"
 
+// httpMethods RouterGroup支持注册的http方法
+var httpMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
 // HandlerFunc 自定义handler类型
 type HandlerFunc func(c *Context)
 
@@ -27,27 +40,176 @@ type RouterGroup struct {
 	// Handlers 当前路由组下所有路由公共Handlers(middleware)
 	Handlers HandlersChain
 	// Routes 路由Map
-	Routes        map[string]map[string]RouteInfo
+	Routes        map[string]map[string]*RouteInfo
 	appNamePrefix string
+	// basePath 通过Group创建的子路由组的路径前缀，包含所有上级路由组的前缀
+	basePath string
+	// names 命名路由，与子路由组共享
+	names map[string]*RouteInfo
+	// host 路由组绑定的host，为nil时匹配所有host
+	host *hostPattern
+	// disableRedirect 为true时，此路由组的路由不参与尾部斜杠和大小写修正的重定向
+	disableRedirect bool
+	// 每个http方法对应一棵路由树，用于请求匹配
+	trees map[string]*node
 }
 
 // RouteInfo 路由详细信息
 // handlers 包含RouterGroup中Handlers和局部middleware(如果有的话)以及主逻辑handler
+// path中可以包含路径参数:
+//  /user/:id 匹配/user/123，参数id为123
+//  /files/*filepath 匹配/files/a/b.txt，参数filepath为a/b.txt
 type RouteInfo struct {
 	handlers HandlersChain
 	path     string
 	method   string
+	name     string
+	group    *RouterGroup
+	// handlerName 主逻辑handler的名称，为空时使用handlers中最后一个函数的名称
+	handlerName string
+	// file和line 注册路由的代码位置
+	file string
+	line int
+	// timeout 请求处理的超时时间，为0时不限制
+	timeout time.Duration
+	// invoker controller action的路由，用于在启动之前检查inject字段的依赖
+	invoker *actionInvoker
+}
+
+// Name 为路由命名，命名后可以通过URLFor生成路由的url，同一路由组中名称不能重复
+//  rg.GET("/user/:id", showUser).Name("user.show")
+//  url, err := app.URLFor("user.show", map[string]interface{}{"id": 1}) // url为/user/1
+func (ri *RouteInfo) Name(name string) *RouteInfo {
+	if name == "" {
+		panic("route name can not be empty")
+	}
+	if _, exists := ri.group.names[name]; exists {
+		panic("multiple registrations for route name " + name)
+	}
+	ri.name = name
+	ri.group.names[name] = ri
+	return ri
+}
+
+// Timeout 设置路由的超时时间，超时后Context的Done被关闭，剩余的handlers不再执行
+// 没有写入响应时返回503，handler中的数据库、RPC调用使用Context作为context.Context时会一起取消
+//  rg.GET("/report", report).Timeout(3 * time.Second)
+func (ri *RouteInfo) Timeout(d time.Duration) *RouteInfo {
+	if d < 0 {
+		panic("route timeout can not be negative")
+	}
+	ri.timeout = d
+	return ri
+}
+
+func (ri *RouteInfo) desc() RouteDesc {
+	d := RouteDesc{
+		Method:      ri.method,
+		Path:        ri.path,
+		Name:        ri.name,
+		Group:       ri.group.name,
+		Handler:     ri.handlerName,
+		Middlewares: make([]string, 0, len(ri.handlers)),
+	}
+	if ri.group.host != nil {
+		d.Host = ri.group.host.pattern
+	}
+	last := len(ri.handlers) - 1
+	for _, h := range ri.handlers[:last] {
+		d.Middlewares = append(d.Middlewares, nameOfFunction(h))
+	}
+	if d.Handler == "" {
+		d.Handler = nameOfFunction(ri.handlers[last])
+	}
+	return d
+}
+
+// methodOrder 返回http方法在httpMethods中的顺序，用于排序
+func methodOrder(method string) int {
+	for i, m := range httpMethods {
+		if m == method {
+			return i
+		}
+	}
+	return len(httpMethods)
+}
+
+// URL 使用params填充路由中的路径参数，生成url
+// params中不是路径参数的项将作为url参数
+func (ri *RouteInfo) URL(params map[string]interface{}) (string, error) {
+	var b strings.Builder
+	used := make(map[string]bool)
+	path := ri.path
+	for i := 0; i < len(path); {
+		c := path[i]
+		if c != ':' && c != '*' {
+			b.WriteByte(c)
+			i++
+			continue
+		}
+		end := strings.IndexByte(path[i:], '/')
+		if end < 0 {
+			end = len(path)
+		} else {
+			end += i
+		}
+		key := path[i+1 : end]
+		v, ok := params[key]
+		if !ok {
+			return "", fmt.Errorf("生成url失败，缺少参数%s route:%s", key, ri.path)
+		}
+		value := fmt.Sprint(v)
+		if c == ':' {
+			if value == "" {
+				return "", fmt.Errorf("生成url失败，参数%s不能为空 route:%s", key, ri.path)
+			}
+			b.WriteString(url.PathEscape(value))
+		} else {
+			segments := strings.Split(strings.TrimPrefix(value, "/"), "/")
+			for j, seg := range segments {
+				segments[j] = url.PathEscape(seg)
+			}
+			b.WriteString(strings.Join(segments, "/"))
+		}
+		used[key] = true
+		i = end
+	}
+
+	query := url.Values{}
+	for k, v := range params {
+		if !used[k] {
+			query.Set(k, fmt.Sprint(v))
+		}
+	}
+	if len(query) > 0 {
+		b.WriteString("?")
+		b.WriteString(query.Encode())
+	}
+	return b.String(), nil
 }
 
 // IRoutes Router接口
 type IRoutes interface {
-	GET(path string, handlers ...HandlerFunc)
-	POST(path string, handlers ...HandlerFunc)
-	PUT(path string, handlers ...HandlerFunc)
-	PATCH(path string, handlers ...HandlerFunc)
-	HEAD(path string, handlers ...HandlerFunc)
-	DELETE(path string, handlers ...HandlerFunc)
-	OPTIONS(path string, handlers ...HandlerFunc)
+	GET(path string, handlers ...HandlerFunc) *RouteInfo
+	POST(path string, handlers ...HandlerFunc) *RouteInfo
+	PUT(path string, handlers ...HandlerFunc) *RouteInfo
+	PATCH(path string, handlers ...HandlerFunc) *RouteInfo
+	HEAD(path string, handlers ...HandlerFunc) *RouteInfo
+	DELETE(path string, handlers ...HandlerFunc) *RouteInfo
+	OPTIONS(path string, handlers ...HandlerFunc) *RouteInfo
+	Any(path string, handlers ...HandlerFunc) []*RouteInfo
+	Handle(method, path string, handler http.Handler) *RouteInfo
+	Mount(prefix string, handler http.Handler)
+}
+
+// mountPathParam Mount注册的通配参数名称
+const mountPathParam = "gwf_mount_path"
+
+// WrapHandler 将http.Handler转换为HandlerFunc，用于在路由中使用net/http的handler
+func WrapHandler(handler http.Handler) HandlerFunc {
+	return func(c *Context) {
+		handler.ServeHTTP(c.Writer, c.Request)
+	}
 }
 
 // NewRouterGroup 初始化
@@ -61,6 +223,52 @@ func (rg *RouterGroup) EnableAppNameAsPathPrefix() {
 	rg.appNamePrefix = "/" + rg.app.config.Name
 }
 
+// Group 创建子路由组，子路由组继承当前路由组的路径前缀和Handlers(middleware)
+// handlers是子路由组额外的middleware，会追加在继承的Handlers之后
+// 子路由组与当前路由组共享路由表，不需要再调用AddRouterGroup
+// 子路由组可以继续调用Group，嵌套层级不受限制:
+//  admin := app.Group("/v1/admin", AdminAuth())
+//  admin.GET("/users", listUsers) // 路由为/v1/admin/users
+//  audit := admin.Group("/audit", AuditLog())
+//  audit.GET("/logs", listLogs) // 路由为/v1/admin/audit/logs，依次执行AdminAuth和AuditLog
+// 子路由组的名称是当前路由组的名称加上prefix，比如app/v1/admin/audit，用于在Routes中区分嵌套的路由组
+// 注意：创建子路由组之后再为当前路由组调用AddMiddleware，新的middleware不会作用于子路由组
+func (rg *RouterGroup) Group(prefix string, handlers ...HandlerFunc) *RouterGroup {
+	if prefix == "" || prefix[0] != '/' {
+		panic("group prefix must begin with '/'")
+	}
+	prefix = strings.TrimRight(prefix, "/")
+
+	if rg.Routes == nil {
+		rg.Routes = make(map[string]map[string]*RouteInfo)
+	}
+	if rg.trees == nil {
+		rg.trees = make(map[string]*node)
+	}
+	if rg.names == nil {
+		rg.names = make(map[string]*RouteInfo)
+	}
+
+	return &RouterGroup{
+		name:            rg.name + prefix,
+		app:             rg.app,
+		Handlers:        rg.combineHandlers(handlers),
+		Routes:          rg.Routes,
+		appNamePrefix:   rg.appNamePrefix,
+		basePath:        rg.basePath + prefix,
+		names:           rg.names,
+		host:            rg.host,
+		disableRedirect: rg.disableRedirect,
+		trees:           rg.trees,
+	}
+}
+
+// DisableRedirect 关闭此路由组的自动重定向，详见Application.SetRedirectTrailingSlash和SetRedirectFixedPath
+// 通过Group创建的子路由组会继承此设置
+func (rg *RouterGroup) DisableRedirect() {
+	rg.disableRedirect = true
+}
+
 // AddMiddleware 添加中间件，整个路由组中的路由共享中间件
 func (rg *RouterGroup) AddMiddleware(handlers ...HandlerFunc) {
 	if handlers == nil {
@@ -70,74 +278,164 @@ func (rg *RouterGroup) AddMiddleware(handlers ...HandlerFunc) {
 }
 
 // GET method get
-func (rg *RouterGroup) GET(path string, handlers ...HandlerFunc) {
-	rg.addRoute(http.MethodGet, path, handlers...)
+func (rg *RouterGroup) GET(path string, handlers ...HandlerFunc) *RouteInfo {
+	return rg.addRoute(http.MethodGet, path, handlers...)
 }
 
 // POST method post
-func (rg *RouterGroup) POST(path string, handlers ...HandlerFunc) {
-	rg.addRoute(http.MethodPost, path, handlers...)
+func (rg *RouterGroup) POST(path string, handlers ...HandlerFunc) *RouteInfo {
+	return rg.addRoute(http.MethodPost, path, handlers...)
 }
 
 // PUT method put
-func (rg *RouterGroup) PUT(path string, handlers ...HandlerFunc) {
-	rg.addRoute(http.MethodPut, path, handlers...)
+func (rg *RouterGroup) PUT(path string, handlers ...HandlerFunc) *RouteInfo {
+	return rg.addRoute(http.MethodPut, path, handlers...)
 }
 
 // PATCH method patch
-func (rg *RouterGroup) PATCH(path string, handlers ...HandlerFunc) {
-	rg.addRoute(http.MethodPatch, path, handlers...)
+func (rg *RouterGroup) PATCH(path string, handlers ...HandlerFunc) *RouteInfo {
+	return rg.addRoute(http.MethodPatch, path, handlers...)
 }
 
 // HEAD method head
-func (rg *RouterGroup) HEAD(path string, handlers ...HandlerFunc) {
-	rg.addRoute(http.MethodHead, path, handlers...)
+func (rg *RouterGroup) HEAD(path string, handlers ...HandlerFunc) *RouteInfo {
+	return rg.addRoute(http.MethodHead, path, handlers...)
 }
 
 // DELETE method delete
-func (rg *RouterGroup) DELETE(path string, handlers ...HandlerFunc) {
-	rg.addRoute(http.MethodDelete, path, handlers...)
+func (rg *RouterGroup) DELETE(path string, handlers ...HandlerFunc) *RouteInfo {
+	return rg.addRoute(http.MethodDelete, path, handlers...)
 }
 
 // OPTIONS method options
-func (rg *RouterGroup) OPTIONS(path string, handlers ...HandlerFunc) {
-	rg.addRoute(http.MethodOptions, path, handlers...)
+func (rg *RouterGroup) OPTIONS(path string, handlers ...HandlerFunc) *RouteInfo {
+	return rg.addRoute(http.MethodOptions, path, handlers...)
 }
 
-func (rg *RouterGroup) addRoute(method, path string, handlers ...HandlerFunc) {
+// Any 为httpMethods中所有的http方法注册路由
+func (rg *RouterGroup) Any(path string, handlers ...HandlerFunc) []*RouteInfo {
+	routeInfos := make([]*RouteInfo, 0, len(httpMethods))
+	for _, method := range httpMethods {
+		routeInfos = append(routeInfos, rg.addRoute(method, path, handlers...))
+	}
+	return routeInfos
+}
 
-	if path != "/" {
-		path = strings.TrimRight(path, "/")
+// Handle 使用http.Handler注册路由，请求会先经过路由组的Handlers(middleware)
+//  rg.Handle(http.MethodGet, "/debug/vars", expvar.Handler())
+func (rg *RouterGroup) Handle(method, path string, handler http.Handler) *RouteInfo {
+	if method == "" || strings.ToUpper(method) != method {
+		panic("http method must be uppercase: " + method)
 	}
-	if path[0] != '/' {
-		panic("path must begin with '/'")
+	if handler == nil {
+		panic("nil handler")
+	}
+	routeInfo := rg.addRoute(method, path, WrapHandler(handler))
+	routeInfo.handlerName = fmt.Sprintf("%T", handler)
+	return routeInfo
+}
+
+// stripRawPathPrefix 去掉u.RawPath中解码后长度为n的前缀，保留%2F等编码的路径
+// RawPath为空或者去掉前缀后与Path不一致时返回空字符串
+func stripRawPathPrefix(u *url.URL, n int) string {
+	raw := u.RawPath
+	if raw == "" {
+		return ""
+	}
+	i := 0
+	for decoded := 0; decoded < n && i < len(raw); decoded++ {
+		if raw[i] == '%' && i+2 < len(raw) {
+			i += 3
+		} else {
+			i++
+		}
+	}
+	rawPath := "/" + raw[i:]
+	if path, err := url.PathUnescape(rawPath); err != nil || path != "/"+u.Path[n:] {
+		return ""
+	}
+	return rawPath
+}
+
+// Mount 将http.Handler挂载到prefix下，prefix及其下所有path的请求都交给handler处理
+// handler收到的请求path已去掉prefix，比如挂载到/metrics时，/metrics/debug对应的path为/debug
+// RawPath同样去掉prefix，%2F等编码的路径会原样保留
+// 请求会先经过路由组的Handlers(middleware)，handler可以是另一个*Application:
+//  rg.Mount("/metrics", promhttp.Handler())
+//  rg.Mount("/legacy", legacyApp)
+func (rg *RouterGroup) Mount(prefix string, handler http.Handler) {
+	if prefix == "" || prefix[0] != '/' {
+		panic("mount prefix must begin with '/'")
 	}
+	if handler == nil {
+		panic("nil handler")
+	}
+	prefix = strings.TrimRight(prefix, "/")
+
+	mounted := func(c *Context) {
+		r := copyRequest(c.Request)
+		rest := c.PathParam(mountPathParam)
+		r.URL.Path = "/" + rest
+		r.URL.RawPath = stripRawPathPrefix(c.Request.URL, len(c.Request.URL.Path)-len(rest))
+		handler.ServeHTTP(c.Writer, r)
+	}
+	handlerName := fmt.Sprintf("%T", handler)
+	var routeInfos []*RouteInfo
+	if prefix != "" {
+		routeInfos = append(routeInfos, rg.Any(prefix, mounted)...)
+	}
+	routeInfos = append(routeInfos, rg.Any(prefix+"/*"+mountPathParam, mounted)...)
+	for _, routeInfo := range routeInfos {
+		routeInfo.handlerName = handlerName
+	}
+}
+
+func (rg *RouterGroup) addRoute(method, path string, handlers ...HandlerFunc) *RouteInfo {
 	if path == "" {
 		panic("invalid path " + path)
 	}
+	if path[0] != '/' {
+		panic("path must begin with '/'")
+	}
+	if path != "/" {
+		path = strings.TrimRight(path, "/")
+		if path == "" {
+			path = "/"
+		}
+	}
 
 	if handlers == nil {
 		panic("nil handlers")
 	}
 
 	path = rg.getUrlPath(path)
+	validatePattern(path)
 	//rg.app.Logger.Debugf("addroute method: %s, path: %s", method, path)
 	if rg.Routes == nil {
-		rg.Routes = make(map[string]map[string]RouteInfo)
+		rg.Routes = make(map[string]map[string]*RouteInfo)
+	}
+	if rg.trees == nil {
+		rg.trees = make(map[string]*node)
+	}
+	if rg.names == nil {
+		rg.names = make(map[string]*RouteInfo)
 	}
 
 	if _, ok := rg.Routes[method]; !ok {
-		rg.Routes[method] = make(map[string]RouteInfo)
+		rg.Routes[method] = make(map[string]*RouteInfo)
+		rg.trees[method] = &node{}
 	}
 
 	if _, exists := rg.Routes[method][path]; exists {
-		if exists {
-			panic("multiple registrations for " + path)
-		}
+		panic("multiple registrations for " + path)
 	}
 
 	totalHandlers := rg.combineHandlers(handlers)
-	rg.Routes[method][path] = RouteInfo{handlers: totalHandlers, path: path, method: method}
+	routeInfo := &RouteInfo{handlers: totalHandlers, path: path, method: method, group: rg}
+	routeInfo.file, routeInfo.line = registrationCaller()
+	rg.trees[method].addRoute(path, routeInfo)
+	rg.Routes[method][path] = routeInfo
+	return routeInfo
 }
 
 func (rg *RouterGroup) combineHandlers(handlers HandlersChain) HandlersChain {
@@ -151,55 +449,111 @@ func (rg *RouterGroup) combineHandlers(handlers HandlersChain) HandlersChain {
 func (rg *RouterGroup) handleRequest(c *Context) bool {
 	path := c.Request.URL.Path
 	method := c.Request.Method
-	routeInfo := rg.match(method, path)
-	if routeInfo.handlers != nil {
-		c.handlers = routeInfo.handlers
-		c.Next()
+	routeInfo, params := rg.match(method, path)
+	if routeInfo != nil {
+		handleRoute(c, routeInfo, params)
 		return true
 	}
 	return false
 }
 
+// handleRoute 使用匹配到的路由处理请求
+func handleRoute(c *Context, routeInfo *RouteInfo, params Params) {
+	c.Params = params
+	c.handlers = routeInfo.handlers
+	if routeInfo.timeout > 0 {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), routeInfo.timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+	}
+	c.Next()
+}
+
 func (rg *RouterGroup) getUrlPath(path string) string {
+	if rg.basePath != "" {
+		if path == "/" {
+			path = rg.basePath
+		} else {
+			path = rg.basePath + path
+		}
+	}
 	if rg.appNamePrefix != "" {
 		path = rg.appNamePrefix + path
 	}
 	return path
 }
 
-func (rg *RouterGroup) match(method, path string) (routeInfo RouteInfo) {
-	if _, ok := rg.Routes[method]; !ok {
+// match 在method对应的路由树中查找path，未找到时routeInfo为nil
+func (rg *RouterGroup) match(method, path string) (routeInfo *RouteInfo, params Params) {
+	root, ok := rg.trees[method]
+	if !ok {
 		return
 	}
-	for k, v := range rg.Routes[method] {
-		if pathMatch(k, path) {
-			routeInfo = v
-			break
-		}
-	}
-	return
+	return root.getValue(path, nil)
 }
 
-func pathMatch(routePath, path string) bool {
-	return routePath == path
-}
+// getControllerPath 返回controller对应的url路径，优先级从高到低:
+//  1、controller实现了ControllerPather接口，使用ControllerPath()的返回值
+//  2、controller嵌入的字段上定义了path标签，比如 *gwf.Controller `path:"/user"`
+//  3、去掉controllerRoot前缀后的包路径+controller名称，
+//     比如root为github.com/foo/bar/controller时，github.com/foo/bar/controller/api中的UserInfoController对应/api/user_info
+//     没有设置root时去掉gopkg.babytree-inc.com/组/项目/pkg/controller/前缀，包路径不在root下时使用完整的包路径
+func getControllerPath(controller interface{}, root string) string {
+	if pather, ok := controller.(ControllerPather); ok {
+		return normalizeControllerPath(pather.ControllerPath())
+	}
+
+	controllerType := reflect.ValueOf(controller).Elem().Type()
+	for i := 0; i < controllerType.NumField(); i++ {
+		field := controllerType.Field(i)
+		if !field.Anonymous {
+			continue
+		}
+		if p, ok := field.Tag.Lookup("path"); ok {
+			return normalizeControllerPath(p)
+		}
+	}
 
-func getControllerPath(controllerType reflect.Type) string {
 	controllerName := controllerType.Name()
 	controllerName = strings.Replace(controllerName, "Controller", "", 1)
 	controllerName = strcase.ToSnake(controllerName)
-	controllerPath := controllerType.PkgPath()
-	p := regexp.MustCompile(`gopkg.babytree-inc.com/[\w]+/[\w]+/pkg/controller/`)
-	controllerPath = p.ReplaceAllString(controllerPath, "")
+	controllerPath := trimControllerRoot(controllerType.PkgPath(), root)
+	if controllerPath == "" {
+		return "/" + controllerName
+	}
 	return fmt.Sprintf("/%s/%s", controllerPath, controllerName)
 }
 
+// defaultControllerRoot 没有设置controllerRoot时去掉的包路径前缀，与之前版本生成的url保持一致
+var defaultControllerRoot = regexp.MustCompile(`gopkg.babytree-inc.com/[\w]+/[\w]+/pkg/controller/`)
+
+// trimControllerRoot 去掉包路径中的root前缀，root为空时去掉defaultControllerRoot
+func trimControllerRoot(pkgPath, root string) string {
+	root = strings.Trim(root, "/")
+	if root == "" {
+		return defaultControllerRoot.ReplaceAllString(pkgPath, "")
+	}
+	if pkgPath == root {
+		return ""
+	}
+	return strings.TrimPrefix(pkgPath, root+"/")
+}
+
+// normalizeControllerPath 保证path以/开始，不以/结束，根路径返回空字符串
+func normalizeControllerPath(p string) string {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return ""
+	}
+	return "/" + p
+}
+
 // 什么是动态路由？动态路由是不通过Routable中的方法注册的路由
 // 动态路由需要在InitRouter中新增如下代码:
 //  app.RegisterDynamicRouter(&api.MobileAskController{})
 // 也可以注册到其他的RouterGroup中
 // 注意：动态路由会注册GET/POST两种方式的请求
-// 为了更好的满足RESTFUL定义，请使用静态路由，使用IRoutes中的方法注册路由
+// 为了更好的满足RESTFUL定义，请使用静态路由，使用IRoutes中的方法注册路由，或者使用Resource注册资源路由
 // options参数有以下调用方式:
 //  // 注册到指定的方法中
 //  RegisterDynamicRouter(&api.MobileAskController{}, []string{"get", "post"})
@@ -249,9 +603,12 @@ func (rg *RouterGroup) RegisterDynamicRouter(controller interface{}, options ...
 		getPathPrefixOption(options[0])
 	}
 
-	controllerType := reflect.ValueOf(controller).Elem().Type()
 	controllerPointerType := reflect.ValueOf(controller).Type()
-	controllerPath := getControllerPath(controllerType)
+	var controllerRoot string
+	if rg.app != nil {
+		controllerRoot = rg.app.controllerRoot
+	}
+	controllerPath := getControllerPath(controller, controllerRoot)
 	for i := 0; i < controllerPointerType.NumMethod(); i++ {
 		actionMethod := controllerPointerType.Method(i)
 		if actionMethod.PkgPath != "" {
@@ -269,44 +626,63 @@ func (rg *RouterGroup) RegisterDynamicRouter(controller interface{}, options ...
 		//是合法的action
 		apiPath := pathPrefix + controllerPath + "/" + actionName
 		//rg.app.Logger.Debugf("添加动态路由: path:%s controller:%s method:%s", apiPath, controllerType.Name(), actionMethod.Name)
-		var action = func(c *Context) {
-			callActionMethod(c, actionMethod.Func.Interface())
-		}
+		invoker := newActionInvoker(actionMethod.Func.Interface())
+		handlers := append(actionFilters(controller, actionMethod.Name), invoker.invoke)
+		var routeInfos []*RouteInfo
 		if len(methodList) == 0 {
-			rg.GET(apiPath, action)
-			rg.POST(apiPath, action)
+			routeInfos = append(routeInfos, rg.GET(apiPath, handlers...))
+			routeInfos = append(routeInfos, rg.POST(apiPath, handlers...))
 		} else {
 			for _, m := range methodList {
 				m = strings.ToUpper(m)
 				switch m {
 				case http.MethodGet:
-					rg.GET(apiPath, action)
+					routeInfos = append(routeInfos, rg.GET(apiPath, handlers...))
 				case http.MethodPost:
-					rg.POST(apiPath, action)
+					routeInfos = append(routeInfos, rg.POST(apiPath, handlers...))
 				case http.MethodPut:
-					rg.PUT(apiPath, action)
+					routeInfos = append(routeInfos, rg.PUT(apiPath, handlers...))
 				case http.MethodPatch:
-					rg.PATCH(apiPath, action)
+					routeInfos = append(routeInfos, rg.PATCH(apiPath, handlers...))
 				case http.MethodHead:
-					rg.HEAD(apiPath, action)
+					routeInfos = append(routeInfos, rg.HEAD(apiPath, handlers...))
 				case http.MethodDelete:
-					rg.DELETE(apiPath, action)
+					routeInfos = append(routeInfos, rg.DELETE(apiPath, handlers...))
 				case http.MethodOptions:
-					rg.OPTIONS(apiPath, action)
+					routeInfos = append(routeInfos, rg.OPTIONS(apiPath, handlers...))
 				default:
 					panic("未知的http方法")
 				}
 			}
 		}
-
+		for _, routeInfo := range routeInfos {
+			routeInfo.handlerName = nameOfFunction(actionMethod.Func.Interface())
+			routeInfo.invoker = invoker
+		}
 	}
 }
 
 // EnableDebugTemplate打开模板功能
 // 在开发环境下是可以调试模板的
+// 模板中可以使用urlFor生成命名路由的url，参数以key、value交替的方式传入:
+//  <a href="{{urlFor "user.show" "id" .User.ID}}">
 func (rg *RouterGroup) EnableTemplate() {
 	SetFuncMap(template.FuncMap{
 		"now": time.Now,
+		"urlFor": func(name string, pairs ...interface{}) (string, error) {
+			if len(pairs)%2 != 0 {
+				return "", fmt.Errorf("urlFor参数错误，key和value必须成对出现 name:%s", name)
+			}
+			params := make(map[string]interface{}, len(pairs)/2)
+			for i := 0; i < len(pairs); i += 2 {
+				key, ok := pairs[i].(string)
+				if !ok {
+					return "", fmt.Errorf("urlFor参数错误，key必须是字符串 name:%s", name)
+				}
+				params[key] = pairs[i+1]
+			}
+			return rg.app.URLFor(name, params)
+		},
 		"staticFileUrl": func(ctx *Context, url string) string {
 			// 开发环境和测试使用/public下的文件
 			if GetConfig().IsDevEnvironment() || GetConfig().IsTestEnvironment() {
@@ -1,6 +1,8 @@
 package gwf
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
 	"time"
@@ -106,3 +108,182 @@ func compareFunc(t *testing.T, a, b interface{}) {
 		t.Error("different functions")
 	}
 }
+
+func TestPathParamRoute(t *testing.T) {
+	rg := NewRouterGroup(nil, "testrgparams")
+	var id int
+	var filepath string
+	rg.GET("/user/:id", func(c *Context) {
+		id = c.PathParamInt("id")
+	})
+	rg.GET("/static/*filepath", func(c *Context) {
+		filepath = c.PathParam("filepath")
+	})
+
+	r, _ := http.NewRequest("GET", "/user/42", nil)
+	assert.True(t, rg.handleRequest(newCtx(nil, r)))
+	assert.Equal(t, 42, id)
+
+	r, _ = http.NewRequest("GET", "/static/css/app.css", nil)
+	assert.True(t, rg.handleRequest(newCtx(nil, r)))
+	assert.Equal(t, "css/app.css", filepath)
+
+	r, _ = http.NewRequest("DELETE", "/user/42", nil)
+	assert.False(t, rg.handleRequest(newCtx(nil, r)))
+}
+
+func TestGroup(t *testing.T) {
+	rg := NewRouterGroup(nil, "testrggroup")
+	var trace []string
+	mw := func(name string) HandlerFunc {
+		return func(c *Context) {
+			trace = append(trace, name)
+		}
+	}
+	rg.AddMiddleware(mw("root"))
+	admin := rg.Group("/v1/admin/", mw("admin"))
+	audit := admin.Group("/audit", mw("audit"))
+	admin.GET("/", mw("index"))
+	audit.GET("/logs/:id", mw("logs"))
+
+	_, ok := rg.Routes["GET"]["/v1/admin"]
+	assert.True(t, ok)
+	_, ok = rg.Routes["GET"]["/v1/admin/audit/logs/:id"]
+	assert.True(t, ok)
+	assert.Equal(t, 1, len(rg.Handlers))
+	assert.Equal(t, 2, len(admin.Handlers))
+	assert.Equal(t, "testrggroup/v1/admin", admin.name)
+	assert.Equal(t, "testrggroup/v1/admin/audit", audit.Routes["GET"]["/v1/admin/audit/logs/:id"].group.name)
+
+	r, _ := http.NewRequest("GET", "/v1/admin/audit/logs/7", nil)
+	assert.True(t, rg.handleRequest(newCtx(nil, r)))
+	assert.Equal(t, []string{"root", "admin", "audit", "logs"}, trace)
+
+	assert.Panics(t, func() { rg.Group("v1") })
+}
+
+func TestRouteURL(t *testing.T) {
+	rg := NewRouterGroup(nil, "testrgname")
+	api := rg.Group("/api")
+	api.GET("/user/:id", func(_ *Context) {}).Name("user.show")
+	api.GET("/files/*filepath", func(_ *Context) {}).Name("files")
+
+	u, err := rg.names["user.show"].URL(map[string]interface{}{"id": 12, "tab": "a b"})
+	assert.Nil(t, err)
+	assert.Equal(t, "/api/user/12?tab=a+b", u)
+
+	u, err = rg.names["files"].URL(map[string]interface{}{"filepath": "css/a b.css"})
+	assert.Nil(t, err)
+	assert.Equal(t, "/api/files/css/a%20b.css", u)
+
+	_, err = rg.names["user.show"].URL(nil)
+	assert.NotNil(t, err)
+
+	assert.Panics(t, func() {
+		rg.POST("/user", func(_ *Context) {}).Name("user.show")
+	})
+}
+
+func TestMountAndHandle(t *testing.T) {
+	rg := NewRouterGroup(nil, "testrgmount")
+	var trace []string
+	rg.AddMiddleware(func(c *Context) {
+		trace = append(trace, c.Request.Method+" "+c.Request.URL.Path)
+	})
+	rg.Group("/tenants/:id").Mount("/files/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.EscapedPath()))
+	}))
+	rg.Handle("PUT", "/raw", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	rg.Any("/any", func(c *Context) {
+		c.String(http.StatusOK, c.Request.Method)
+	})
+
+	tests := []struct {
+		method, path string
+		code         int
+		body         string
+	}{
+		{"GET", "/tenants/3/files", 200, "/"},
+		{"DELETE", "/tenants/3/files/", 200, "/"},
+		{"GET", "/tenants/3/files/a/b.txt", 200, "/a/b.txt"},
+		{"GET", "/tenants/3/files/a%2Fb/c%20d.txt", 200, "/a%2Fb/c%20d.txt"},
+		{"PUT", "/raw", 202, ""},
+		{"PATCH", "/any", 200, "PATCH"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest(tt.method, tt.path, nil)
+		c := newCtx(nil, r)
+		c.Writer = NewResponseWriter(w, nil, nil, nil)
+		assert.True(t, rg.handleRequest(c), tt.path)
+		c.Writer.WriteHeaderNow()
+		assert.Equal(t, tt.code, w.Code, tt.path)
+		assert.Equal(t, tt.body, w.Body.String(), tt.path)
+	}
+	assert.Equal(t, len(tests), len(trace))
+	assert.Equal(t, "GET /tenants/3/files/a/b.txt", trace[2])
+
+	// 编码的斜杠不能被解码成路径分隔符
+	m := NewRouterGroup(nil, "testrgmountencoded")
+	m.Mount("/m", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.EscapedPath()))
+	}))
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/m/a%2Fb", nil)
+	c := newCtx(nil, r)
+	c.Writer = NewResponseWriter(w, nil, nil, nil)
+	assert.True(t, m.handleRequest(c))
+	assert.Equal(t, "/a%2Fb", w.Body.String())
+
+	assert.Panics(t, func() { rg.Handle("get", "/x", http.NotFoundHandler()) })
+}
+
+func TestTrimControllerRoot(t *testing.T) {
+	// 没有设置root时保持之前版本的url
+	assert.Equal(t, "api", trimControllerRoot("gopkg.babytree-inc.com/foo/bar/pkg/controller/api", ""))
+	assert.Equal(t, "github.com/panda-win/gwf", trimControllerRoot("github.com/panda-win/gwf", ""))
+
+	assert.Equal(t, "api/v1", trimControllerRoot("github.com/foo/bar/controller/api/v1", "github.com/foo/bar/controller/"))
+	assert.Equal(t, "", trimControllerRoot("github.com/foo/bar/controller", "github.com/foo/bar/controller"))
+	assert.Equal(t, "github.com/foo/baz", trimControllerRoot("github.com/foo/baz", "github.com/foo/bar"))
+}
+
+type taggedController struct {
+	*Controller `path:"/v2/tagged/"`
+}
+
+func (cc *taggedController) ShowAction(c *Context) {}
+
+type patherController struct {
+	*Controller
+}
+
+func (cc *patherController) ControllerPath() string {
+	return "custom"
+}
+
+func (cc *patherController) ListAction(c *Context) {}
+
+func TestRegisterDynamicRouterWithControllerRoot(t *testing.T) {
+	app := newTestApplication()
+	app.SetControllerRoot("github.com/panda-win")
+	app.RegisterDynamicRouter(&testController{})
+	_, ok := app.RouterGroup.Routes["GET"]["/gwf/test/my"]
+	assert.True(t, ok)
+
+	app.SetControllerRoot("github.com/panda-win/gwf/")
+	rg := NewRouterGroup(app, "root")
+	rg.RegisterDynamicRouter(&testController{})
+	_, ok = rg.Routes["GET"]["/test/my"]
+	assert.True(t, ok)
+
+	rg.RegisterDynamicRouter(&taggedController{})
+	_, ok = rg.Routes["GET"]["/v2/tagged/show"]
+	assert.True(t, ok)
+
+	rg.RegisterDynamicRouter(&patherController{})
+	_, ok = rg.Routes["GET"]["/custom/list"]
+	assert.True(t, ok)
+}
@@ -0,0 +1,206 @@
+package gwf
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ServerSentEvent Server-Sent Events中的一个事件
+// Data是string或者[]byte时原样输出，多行数据会输出多个data行，其他类型编码为json
+type ServerSentEvent struct {
+	// ID 事件id，客户端重连时通过Last-Event-ID请求头带回最后收到的id
+	ID    string
+	Event string
+	// Retry 客户端重连的等待时间，为0时不输出
+	Retry time.Duration
+	Data  interface{}
+}
+
+// sseNewlineReplacer 去掉id和event中的换行符，避免拆分出额外的字段
+var sseNewlineReplacer = strings.NewReplacer("\r", "", "\n", "")
+
+// sseLineEndReplacer 将data中的\r\n和\r转换为\n
+var sseLineEndReplacer = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+// WriteTo 按text/event-stream格式将事件写入w
+func (e ServerSentEvent) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	if e.ID != "" {
+		b.WriteString("id: " + sseNewlineReplacer.Replace(e.ID) + "\n")
+	}
+	if e.Event != "" {
+		b.WriteString("event: " + sseNewlineReplacer.Replace(e.Event) + "\n")
+	}
+	if e.Retry > 0 {
+		b.WriteString(fmt.Sprintf("retry: %d\n", e.Retry.Milliseconds()))
+	}
+
+	var data string
+	switch v := e.Data.(type) {
+	case string:
+		data = v
+	case []byte:
+		data = string(v)
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return 0, err
+		}
+		data = string(encoded)
+	}
+	// \r\n以及单独的\r都是行结束符，统一转换为\n之后再拆分，避免data中拆分出额外的id、event等字段
+	data = sseLineEndReplacer.Replace(data)
+	for _, line := range strings.Split(data, "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// sseHeartbeat 心跳使用注释行，客户端会忽略
+const sseHeartbeat = ": heartbeat\n\n"
+
+// Stream 流式输出响应，每次调用step之后立即发送给客户端，step返回false时结束
+// 客户端断开连接或者路由超时时不再调用step，返回true
+// 只在两次调用step之间检查连接，step需要等待数据时必须同时等待c.Done()，否则客户端断开连接之后会一直阻塞
+//  clientGone := c.Stream(func(w io.Writer) bool {
+//  	select {
+//  	case row, ok := <-rows:
+//  		if !ok {
+//  			return false
+//  		}
+//  		fmt.Fprintln(w, row)
+//  		return true
+//  	case <-c.Done():
+//  		return false
+//  	}
+//  })
+func (c *Context) Stream(step func(w io.Writer) bool) (clientGone bool) {
+	for {
+		if c.Err() != nil {
+			return true
+		}
+		keepOpen := step(c.Writer)
+		c.Writer.Flush()
+		if !keepOpen {
+			// step因为c.Done()返回false时同样是客户端断开连接
+			return c.Err() != nil
+		}
+	}
+}
+
+// setSSEHeader 写入Server-Sent Events的响应头，已经写入响应时不再修改
+func (c *Context) setSSEHeader() {
+	if c.Writer.Written() {
+		return
+	}
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream; charset=UTF-8")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// 关闭nginx的缓冲，否则事件不能及时发送给客户端
+	header.Set("X-Accel-Buffering", "no")
+}
+
+// SendEvent 发送一个Server-Sent Event并立即发送给客户端，第一次调用时写入响应头
+// 客户端已经断开连接或者路由超时时不再发送，返回c.Err()
+func (c *Context) SendEvent(e ServerSentEvent) error {
+	if err := c.Err(); err != nil {
+		return err
+	}
+	c.setSSEHeader()
+	if _, err := e.WriteTo(c.Writer); err != nil {
+		return err
+	}
+	c.Writer.Flush()
+	return nil
+}
+
+// SSEvent 发送名称为event的Server-Sent Event，客户端断开连接等原因发送失败时返回error
+// 调用方需要自己使用return控制程序流程
+//  if err := c.SSEvent("progress", map[string]int{"done": 10, "total": 100}); err != nil {
+//  	return
+//  }
+func (c *Context) SSEvent(event string, data interface{}) error {
+	return c.SendEvent(ServerSentEvent{Event: event, Data: data})
+}
+
+// LastEventID 客户端重连时通过Last-Event-ID请求头带回的最后收到的事件id
+func (c *Context) LastEventID() string {
+	return c.Request.Header.Get("Last-Event-ID")
+}
+
+// sseOptions SSEStream的选项
+type sseOptions struct {
+	resume func(lastEventID string) []ServerSentEvent
+}
+
+// SSEOption SSEStream的选项
+type SSEOption func(*sseOptions)
+
+// SSEResume 设置断线重连的处理函数，客户端带有Last-Event-ID重连时，
+// SSEStream在推送events之前先调用resume，并推送返回的lastEventID之后的事件
+func SSEResume(resume func(lastEventID string) []ServerSentEvent) SSEOption {
+	return func(o *sseOptions) {
+		o.resume = resume
+	}
+}
+
+// SSEStream 持续推送events中的事件，直到events被关闭、客户端断开连接或者路由超时
+// heartbeat大于0时每隔heartbeat发送一次心跳，避免代理因为连接空闲断开
+// 客户端断开连接、路由超时或者发送失败时返回true
+//  events := export.Subscribe()
+//  c.SSEStream(15*time.Second, events, gwf.SSEResume(func(lastEventID string) []gwf.ServerSentEvent {
+//  	// 补发客户端断开期间错过的进度
+//  	return export.EventsAfter(lastEventID)
+//  }))
+func (c *Context) SSEStream(heartbeat time.Duration, events <-chan ServerSentEvent, opts ...SSEOption) (clientGone bool) {
+	var options sseOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	c.setSSEHeader()
+	c.Writer.Flush()
+
+	if lastEventID := c.LastEventID(); lastEventID != "" && options.resume != nil {
+		for _, e := range options.resume(lastEventID) {
+			if c.Err() != nil {
+				return true
+			}
+			if err := c.SendEvent(e); err != nil {
+				return true
+			}
+		}
+	}
+
+	var tick <-chan time.Time
+	if heartbeat > 0 {
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-c.Done():
+			return true
+		case e, ok := <-events:
+			if !ok {
+				return false
+			}
+			if err := c.SendEvent(e); err != nil {
+				return true
+			}
+		case <-tick:
+			if _, err := io.WriteString(c.Writer, sseHeartbeat); err != nil {
+				return true
+			}
+			c.Writer.Flush()
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package gwf
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerSentEvent(t *testing.T) {
+	var b strings.Builder
+	_, err := ServerSentEvent{ID: "1\n", Event: "progress", Retry: 3 * time.Second, Data: "a\nb"}.WriteTo(&b)
+	assert.Nil(t, err)
+	assert.Equal(t, "id: 1\nevent: progress\nretry: 3000\ndata: a\ndata: b\n\n", b.String())
+
+	b.Reset()
+	_, err = ServerSentEvent{Data: map[string]int{"done": 1}}.WriteTo(&b)
+	assert.Nil(t, err)
+	assert.Equal(t, "data: {\"done\":1}\n\n", b.String())
+
+	// 单独的\r也是行结束符，data中不能拆分出额外的id、event字段
+	b.Reset()
+	_, err = ServerSentEvent{Data: "x\rid: 9\revent: admin\r\ny"}.WriteTo(&b)
+	assert.Nil(t, err)
+	assert.Equal(t, "data: x\ndata: id: 9\ndata: event: admin\ndata: y\n\n", b.String())
+}
+
+func TestStream(t *testing.T) {
+	app := newTestApplication()
+	app.GET("/export", func(c *Context) {
+		i := 0
+		clientGone := c.Stream(func(w io.Writer) bool {
+			i++
+			fmt.Fprintf(w, "row%d\n", i)
+			return i < 3
+		})
+		assert.False(t, clientGone)
+	})
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/export", nil)
+	app.ServeHTTP(w, r)
+	assert.True(t, w.Flushed)
+	assert.Equal(t, "row1\nrow2\nrow3\n", w.Body.String())
+
+	// 客户端断开连接后不再调用step
+	ctx, cancel := context.WithCancel(context.Background())
+	r, _ = http.NewRequest("GET", "/", nil)
+	c := newCtx(nil, r.WithContext(ctx))
+	c.Writer = NewResponseWriter(httptest.NewRecorder(), nil, nil, nil)
+	calls := 0
+	clientGone := c.Stream(func(w io.Writer) bool {
+		calls++
+		cancel()
+		return true
+	})
+	assert.True(t, clientGone)
+	assert.Equal(t, 1, calls)
+
+	// 等待数据的step同时等待c.Done()，客户端断开连接后立即结束
+	result := make(chan bool, 1)
+	app.GET("/blocked", func(c *Context) {
+		rows := make(chan string)
+		result <- c.Stream(func(w io.Writer) bool {
+			select {
+			case row := <-rows:
+				fmt.Fprintln(w, row)
+				return true
+			case <-c.Done():
+				return false
+			}
+		})
+	})
+	server := httptest.NewServer(app)
+	defer server.Close()
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	r, _ = http.NewRequest("GET", server.URL+"/blocked", nil)
+	_, err := http.DefaultClient.Do(r.WithContext(ctx))
+	assert.NotNil(t, err)
+	select {
+	case clientGone := <-result:
+		assert.True(t, clientGone)
+	case <-time.After(time.Second):
+		t.Error("客户端断开连接后Stream没有结束")
+	}
+}
+
+func TestSSEStream(t *testing.T) {
+	app := newTestApplication()
+	app.GET("/events", func(c *Context) {
+		events := make(chan ServerSentEvent)
+		go func() {
+			defer close(events)
+			events <- ServerSentEvent{ID: "43", Data: "live"}
+			time.Sleep(30 * time.Millisecond)
+			events <- ServerSentEvent{Event: "done", Data: "ok"}
+		}()
+		assert.False(t, c.SSEStream(10*time.Millisecond, events, SSEResume(func(lastEventID string) []ServerSentEvent {
+			assert.Equal(t, "41", lastEventID)
+			return []ServerSentEvent{{ID: "42", Data: "missed"}}
+		})))
+	})
+	app.GET("/event", func(c *Context) {
+		assert.Nil(t, c.SSEvent("progress", 50))
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/events", nil)
+	r.Header.Set("Last-Event-ID", "41")
+	app.ServeHTTP(w, r)
+	assert.Equal(t, "text/event-stream; charset=UTF-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+	body := w.Body.String()
+	assert.True(t, strings.HasPrefix(body, "id: 42\ndata: missed\n\nid: 43\ndata: live\n\n"), body)
+	assert.Contains(t, body, sseHeartbeat)
+	assert.True(t, strings.HasSuffix(body, "event: done\ndata: ok\n\n"), body)
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("GET", "/event", nil)
+	app.ServeHTTP(w, r)
+	assert.Equal(t, "event: progress\ndata: 50\n\n", w.Body.String())
+
+	// 客户端断开连接后发送失败，不会panic
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r, _ = http.NewRequest("GET", "/", nil)
+	c := newCtx(nil, r.WithContext(ctx))
+	rec := httptest.NewRecorder()
+	c.Writer = NewResponseWriter(rec, nil, nil, nil)
+	assert.Equal(t, context.Canceled, c.SSEvent("progress", 1))
+	assert.Equal(t, "", rec.Body.String())
+
+	// 路由超时后结束推送
+	app.GET("/timeout", func(c *Context) {
+		assert.True(t, c.SSEStream(0, make(chan ServerSentEvent)))
+	}).Timeout(10 * time.Millisecond)
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("GET", "/timeout", nil)
+	app.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
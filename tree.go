@@ -0,0 +1,267 @@
+package gwf
+
+import (
+	"strings"
+)
+
+// Param 路径参数，由路由中的:name或者*name匹配得到
+type Param struct {
+	Key   string
+	Value string
+}
+
+// Params 路径参数列表，顺序与路由中参数出现的顺序一致
+type Params []Param
+
+// Get 返回名称为key的第一个参数值，存在时exists为true
+func (ps Params) Get(key string) (value string, exists bool) {
+	for _, p := range ps {
+		if p.Key == key {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+// ByName 返回名称为key的第一个参数值，不存在时返回空字符串
+func (ps Params) ByName(key string) string {
+	v, _ := ps.Get(key)
+	return v
+}
+
+type nodeType uint8
+
+const (
+	nodeTypeStatic nodeType = iota
+	nodeTypeParam
+	nodeTypeCatchAll
+)
+
+// node 是路由基数树(radix tree)的节点
+// 静态节点的path是路径片段，参数节点和通配节点的path是参数名称
+// 匹配优先级: 静态路径 > 命名参数(:name) > 通配参数(*name)
+// 高优先级的分支匹配失败时会回溯尝试低优先级的分支
+type node struct {
+	path  string
+	nType nodeType
+	// indices 静态子节点path的首字节，与children一一对应
+	indices       string
+	children      []*node
+	paramChild    *node
+	catchAllChild *node
+	// route 非nil时表示此节点是某个路由的终点
+	route *RouteInfo
+}
+
+// validatePattern 校验路由path中参数的定义
+//  :name 匹配一段不含'/'的非空路径
+//  *name 匹配剩余的全部路径，只能出现在路由最后
+func validatePattern(path string) {
+	names := make(map[string]bool)
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if c != ':' && c != '*' {
+			continue
+		}
+		if i == 0 || path[i-1] != '/' {
+			panic("参数必须是完整的一段路径 path:" + path)
+		}
+		end := strings.IndexByte(path[i:], '/')
+		if end < 0 {
+			end = len(path)
+		} else {
+			end += i
+		}
+		name := path[i+1 : end]
+		if name == "" || strings.ContainsAny(name, ":*") {
+			panic("参数名称不合法 path:" + path)
+		}
+		if c == '*' && end != len(path) {
+			panic("通配参数只能出现在路由最后 path:" + path)
+		}
+		if names[name] {
+			panic("参数名称重复 name:" + name + " path:" + path)
+		}
+		names[name] = true
+		i = end - 1
+	}
+}
+
+// addRoute 将path添加到以n为根的树中，path需要先经过validatePattern校验
+func (n *node) addRoute(path string, route *RouteInfo) {
+	fullPath := path
+	cur := n
+	for path != "" {
+		switch path[0] {
+		case ':':
+			end := strings.IndexByte(path, '/')
+			if end < 0 {
+				end = len(path)
+			}
+			name := path[1:end]
+			if cur.paramChild == nil {
+				cur.paramChild = &node{path: name, nType: nodeTypeParam}
+			} else if cur.paramChild.path != name {
+				panic("参数:" + name + "与已注册的参数:" + cur.paramChild.path + "冲突 path:" + fullPath)
+			}
+			cur = cur.paramChild
+			path = path[end:]
+		case '*':
+			name := path[1:]
+			if cur.catchAllChild == nil {
+				cur.catchAllChild = &node{path: name, nType: nodeTypeCatchAll}
+			} else if cur.catchAllChild.path != name {
+				panic("通配参数*" + name + "与已注册的通配参数*" + cur.catchAllChild.path + "冲突 path:" + fullPath)
+			}
+			cur = cur.catchAllChild
+			path = ""
+		default:
+			end := strings.IndexAny(path, ":*")
+			if end < 0 {
+				end = len(path)
+			}
+			cur = cur.addStatic(path[:end])
+			path = path[end:]
+		}
+	}
+
+	if cur.route != nil {
+		panic("multiple registrations for " + fullPath)
+	}
+	cur.route = route
+}
+
+// addStatic 在n下插入静态路径片段seg，返回seg终点所在的节点
+func (n *node) addStatic(seg string) *node {
+	for seg != "" {
+		child := n.staticChild(seg[0])
+		if child == nil {
+			child = &node{path: seg}
+			n.indices += string(seg[0])
+			n.children = append(n.children, child)
+			return child
+		}
+
+		l := longestCommonPrefix(seg, child.path)
+		if l < len(child.path) {
+			// 拆分已有节点，公共前缀保留在原节点上
+			tail := &node{
+				path:          child.path[l:],
+				indices:       child.indices,
+				children:      child.children,
+				paramChild:    child.paramChild,
+				catchAllChild: child.catchAllChild,
+				route:         child.route,
+			}
+			*child = node{
+				path:     child.path[:l],
+				indices:  string(tail.path[0]),
+				children: []*node{tail},
+			}
+		}
+		seg = seg[l:]
+		n = child
+	}
+	return n
+}
+
+func (n *node) staticChild(c byte) *node {
+	for i := 0; i < len(n.indices); i++ {
+		if n.indices[i] == c {
+			return n.children[i]
+		}
+	}
+	return nil
+}
+
+// getValue 匹配剩余路径path，返回匹配到的路由以及路径参数
+func (n *node) getValue(path string, ps Params) (*RouteInfo, Params) {
+	if path == "" {
+		if n.route != nil {
+			return n.route, ps
+		}
+		// 通配参数可以匹配空字符串，比如/files/*filepath匹配/files/
+		if n.catchAllChild != nil {
+			return n.catchAllChild.route, append(ps, Param{Key: n.catchAllChild.path})
+		}
+		return nil, ps
+	}
+
+	if child := n.staticChild(path[0]); child != nil && strings.HasPrefix(path, child.path) {
+		if route, params := child.getValue(path[len(child.path):], ps); route != nil {
+			return route, params
+		}
+	}
+
+	if n.paramChild != nil {
+		end := strings.IndexByte(path, '/')
+		if end < 0 {
+			end = len(path)
+		}
+		if end > 0 {
+			p := Param{Key: n.paramChild.path, Value: path[:end]}
+			if route, params := n.paramChild.getValue(path[end:], append(ps, p)); route != nil {
+				return route, params
+			}
+		}
+	}
+
+	if n.catchAllChild != nil {
+		return n.catchAllChild.route, append(ps, Param{Key: n.catchAllChild.path, Value: path})
+	}
+
+	return nil, ps
+}
+
+// findCaseInsensitive 不区分大小写匹配剩余路径path，buf中是已匹配的路径
+// 返回的路径中静态片段使用注册路由时的大小写，路径参数保持请求中的原值
+func (n *node) findCaseInsensitive(path string, buf []byte) ([]byte, *RouteInfo) {
+	if path == "" {
+		if n.route != nil {
+			return buf, n.route
+		}
+		if n.catchAllChild != nil {
+			return buf, n.catchAllChild.route
+		}
+		return nil, nil
+	}
+
+	for _, child := range n.children {
+		l := len(child.path)
+		if len(path) >= l && strings.EqualFold(path[:l], child.path) {
+			if fixed, route := child.findCaseInsensitive(path[l:], append(buf, child.path...)); route != nil {
+				return fixed, route
+			}
+		}
+	}
+
+	if n.paramChild != nil {
+		end := strings.IndexByte(path, '/')
+		if end < 0 {
+			end = len(path)
+		}
+		if end > 0 {
+			if fixed, route := n.paramChild.findCaseInsensitive(path[end:], append(buf, path[:end]...)); route != nil {
+				return fixed, route
+			}
+		}
+	}
+
+	if n.catchAllChild != nil {
+		return append(buf, path...), n.catchAllChild.route
+	}
+
+	return nil, nil
+}
+
+func longestCommonPrefix(a, b string) int {
+	max := len(a)
+	if len(b) < max {
+		max = len(b)
+	}
+	i := 0
+	for i < max && a[i] == b[i] {
+		i++
+	}
+	return i
+}
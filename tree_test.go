@@ -0,0 +1,76 @@
+package gwf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func buildTestTree(paths ...string) *node {
+	root := &node{}
+	for _, p := range paths {
+		validatePattern(p)
+		root.addRoute(p, &RouteInfo{path: p})
+	}
+	return root
+}
+
+func TestTreeGetValue(t *testing.T) {
+	root := buildTestTree(
+		"/",
+		"/user/new",
+		"/user/:id",
+		"/user/:id/profile",
+		"/users",
+		"/files/*filepath",
+		"/files/readme",
+		"/u/:name/*rest",
+	)
+
+	tests := []struct {
+		path   string
+		route  string
+		params Params
+	}{
+		{"/", "/", nil},
+		{"/user/new", "/user/new", nil},
+		{"/user/123", "/user/:id", Params{{"id", "123"}}},
+		{"/user/123/profile", "/user/:id/profile", Params{{"id", "123"}}},
+		{"/users", "/users", nil},
+		{"/files/readme", "/files/readme", nil},
+		{"/files/a/b.txt", "/files/*filepath", Params{{"filepath", "a/b.txt"}}},
+		{"/files/", "/files/*filepath", Params{{"filepath", ""}}},
+		{"/u/tom/x/y", "/u/:name/*rest", Params{{"name", "tom"}, {"rest", "x/y"}}},
+	}
+	for _, tt := range tests {
+		route, params := root.getValue(tt.path, nil)
+		if assert.NotNil(t, route, tt.path) {
+			assert.Equal(t, tt.route, route.path, tt.path)
+			assert.Equal(t, tt.params, params, tt.path)
+		}
+	}
+
+	for _, p := range []string{"/user", "/user/", "/user/123/profile/x", "/files", "/nope"} {
+		route, _ := root.getValue(p, nil)
+		assert.Nil(t, route, p)
+	}
+}
+
+func TestTreeBacktracking(t *testing.T) {
+	root := buildTestTree("/a/b/c", "/a/:x/d")
+	route, params := root.getValue("/a/b/d", nil)
+	if assert.NotNil(t, route) {
+		assert.Equal(t, "/a/:x/d", route.path)
+		assert.Equal(t, "b", params.ByName("x"))
+	}
+}
+
+func TestTreeConflicts(t *testing.T) {
+	assert.Panics(t, func() { buildTestTree("/user/:id", "/user/:name") })
+	assert.Panics(t, func() { buildTestTree("/files/*a", "/files/*b") })
+	assert.Panics(t, func() { buildTestTree("/user/:id", "/user/:id") })
+	assert.Panics(t, func() { validatePattern("/user:id") })
+	assert.Panics(t, func() { validatePattern("/user/:") })
+	assert.Panics(t, func() { validatePattern("/files/*path/x") })
+	assert.Panics(t, func() { validatePattern("/a/:id/b/:id") })
+}
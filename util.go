@@ -5,6 +5,8 @@ import (
 	"mime/multipart"
 	"os"
 	"path/filepath"
+	"reflect"
+	"runtime"
 )
 
 func fileExist(filename string) bool {
@@ -31,6 +33,11 @@ func getDeployRootPath(evalSymlinks bool) (string, error) {
 	return filepath.Dir(p), nil
 }
 
+// nameOfFunction 返回函数的完整名称，包含包路径
+func nameOfFunction(f interface{}) string {
+	return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+}
+
 // SaveUploadedFile用于存储上传文件到文件路径dst
 func SaveUploadedFile(file *multipart.FileHeader, dst string) error {
 	src, err := file.Open()
@@ -0,0 +1,670 @@
+package gwf
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// LangZh 中文错误信息
+	LangZh = "zh"
+	// LangEn 英文错误信息
+	LangEn = "en"
+)
+
+// langs 支持的错误信息语言，第一个是默认语言
+var langs = []string{LangZh, LangEn}
+
+// validateTagName 校验规则的tag
+const validateTagName = "validate"
+
+// FieldError 字段的校验错误
+type FieldError struct {
+	// Field 字段名称，依次使用json、form、path、header、cookie tag
+	Field string `json:"field"`
+	// Namespace 包含上级struct的字段名称，比如User.address.city
+	Namespace string `json:"namespace"`
+	// Tag 校验失败的规则，比如required、max
+	Tag string `json:"tag"`
+	// Param 规则的参数，比如max=100中的100
+	Param string `json:"param"`
+	// messages 各语言的错误信息
+	messages map[string]string
+}
+
+// Message 返回lang语言的错误信息，不支持的语言返回中文错误信息
+func (fe FieldError) Message(lang string) string {
+	if msg, ok := fe.messages[lang]; ok {
+		return msg
+	}
+	return fe.messages[LangZh]
+}
+
+// ValidationErrors 参数校验错误，包含所有校验失败的字段
+// Bind系列函数校验失败时返回此错误，typed action返回此错误时响应400
+type ValidationErrors []FieldError
+
+func (ve ValidationErrors) Error() string {
+	return ve.Message(LangZh)
+}
+
+// Message 返回lang语言的错误信息，多个字段的错误信息以"; "分隔
+func (ve ValidationErrors) Message(lang string) string {
+	messages := make([]string, 0, len(ve))
+	for _, fe := range ve {
+		messages = append(messages, fe.Message(lang))
+	}
+	return strings.Join(messages, "; ")
+}
+
+// ValidationFunc 校验规则，field是字段的值，param是规则的参数，校验通过返回true
+type ValidationFunc func(field reflect.Value, param string) bool
+
+// builtinValidations 内置的校验规则，min、max、len、gt、gte、lt、lte对于字符串比较字符数，
+// 对于slice、map、array比较元素个数，对于数字比较数值
+//  required   值不能是零值，指针、slice、map不能是nil
+//  omitempty  值是零值时不再校验其他规则
+//  len=10 min=1 max=100 gt=0 gte=1 lt=100 lte=99
+//  eq=1 ne=0  等于、不等于，字符串比较字符串本身
+//  oneof=a b  是空格分隔的值中的一个
+//  email url numeric alpha alphanum
+var builtinValidations = map[string]ValidationFunc{
+	"required": hasValue,
+	"len":      sizeValidation(func(c int) bool { return c == 0 }),
+	"min":      sizeValidation(func(c int) bool { return c >= 0 }),
+	"max":      sizeValidation(func(c int) bool { return c <= 0 }),
+	"gt":       sizeValidation(func(c int) bool { return c > 0 }),
+	"gte":      sizeValidation(func(c int) bool { return c >= 0 }),
+	"lt":       sizeValidation(func(c int) bool { return c < 0 }),
+	"lte":      sizeValidation(func(c int) bool { return c <= 0 }),
+	"eq":       isEqual,
+	"ne":       func(v reflect.Value, p string) bool { return !isEqual(v, p) },
+	"oneof":    isOneOf,
+	"email":    isEmail,
+	"url":      isURL,
+	"numeric":  isNumeric,
+	"alpha": func(v reflect.Value, p string) bool {
+		return matchRunes(v, unicode.IsLetter)
+	},
+	"alphanum": func(v reflect.Value, p string) bool {
+		return matchRunes(v, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) })
+	},
+}
+
+// sizeTags 错误信息区分字符串、元素个数和数值的规则
+var sizeTags = map[string]bool{"len": true, "min": true, "max": true, "gt": true, "gte": true, "lt": true, "lte": true}
+
+// builtinRuleChecks 内置规则的参数以及字段类型检查，解析struct时执行，t是去掉指针之后的字段类型
+var builtinRuleChecks = map[string]func(t reflect.Type, param string) error{
+	"len":   checkSizeRule,
+	"min":   checkSizeRule,
+	"max":   checkSizeRule,
+	"gt":    checkSizeRule,
+	"gte":   checkSizeRule,
+	"lt":    checkSizeRule,
+	"lte":   checkSizeRule,
+	"eq":    checkEqualRule,
+	"ne":    checkEqualRule,
+	"oneof": checkOneOfRule,
+}
+
+// builtinMessages 内置规则的错误信息，{0}是字段名称，{1}是规则的参数
+// len、min、max等规则的key带有_string、_items、_number后缀
+var builtinMessages = map[string]map[string]string{
+	LangZh: {
+		"required":    "{0}为必填字段",
+		"len_string":  "{0}长度必须是{1}个字符",
+		"len_items":   "{0}必须包含{1}项",
+		"len_number":  "{0}必须等于{1}",
+		"min_string":  "{0}长度必须至少为{1}个字符",
+		"min_items":   "{0}必须至少包含{1}项",
+		"min_number":  "{0}最小只能为{1}",
+		"max_string":  "{0}长度不能超过{1}个字符",
+		"max_items":   "{0}最多只能包含{1}项",
+		"max_number":  "{0}必须小于或等于{1}",
+		"gt_string":   "{0}长度必须大于{1}个字符",
+		"gt_items":    "{0}必须大于{1}项",
+		"gt_number":   "{0}必须大于{1}",
+		"gte_string":  "{0}长度必须至少为{1}个字符",
+		"gte_items":   "{0}必须至少包含{1}项",
+		"gte_number":  "{0}必须大于或等于{1}",
+		"lt_string":   "{0}长度必须小于{1}个字符",
+		"lt_items":    "{0}必须包含少于{1}项",
+		"lt_number":   "{0}必须小于{1}",
+		"lte_string":  "{0}长度不能超过{1}个字符",
+		"lte_items":   "{0}最多只能包含{1}项",
+		"lte_number":  "{0}必须小于或等于{1}",
+		"eq":          "{0}不等于{1}",
+		"ne":          "{0}不能等于{1}",
+		"oneof":       "{0}必须是[{1}]中的一个",
+		"email":       "{0}必须是一个有效的邮箱",
+		"url":         "{0}必须是一个有效的URL",
+		"numeric":     "{0}必须是一个有效的数值",
+		"alpha":       "{0}只能包含字母",
+		"alphanum":    "{0}只能包含字母和数字",
+		defaultMsgKey: "{0}校验失败",
+	},
+	LangEn: {
+		"required":    "{0} is a required field",
+		"len_string":  "{0} must be {1} characters in length",
+		"len_items":   "{0} must contain {1} items",
+		"len_number":  "{0} must be equal to {1}",
+		"min_string":  "{0} must be at least {1} characters in length",
+		"min_items":   "{0} must contain at least {1} items",
+		"min_number":  "{0} must be {1} or greater",
+		"max_string":  "{0} must be a maximum of {1} characters in length",
+		"max_items":   "{0} must contain at maximum {1} items",
+		"max_number":  "{0} must be {1} or less",
+		"gt_string":   "{0} must be greater than {1} characters in length",
+		"gt_items":    "{0} must contain more than {1} items",
+		"gt_number":   "{0} must be greater than {1}",
+		"gte_string":  "{0} must be at least {1} characters in length",
+		"gte_items":   "{0} must contain at least {1} items",
+		"gte_number":  "{0} must be {1} or greater",
+		"lt_string":   "{0} must be less than {1} characters in length",
+		"lt_items":    "{0} must contain less than {1} items",
+		"lt_number":   "{0} must be less than {1}",
+		"lte_string":  "{0} must be at maximum {1} characters in length",
+		"lte_items":   "{0} must contain at maximum {1} items",
+		"lte_number":  "{0} must be {1} or less",
+		"eq":          "{0} is not equal to {1}",
+		"ne":          "{0} should not be equal to {1}",
+		"oneof":       "{0} must be one of [{1}]",
+		"email":       "{0} must be a valid email address",
+		"url":         "{0} must be a valid URL",
+		"numeric":     "{0} must be a valid numeric value",
+		"alpha":       "{0} can only contain alphabetic characters",
+		"alphanum":    "{0} can only contain alphanumeric characters",
+		defaultMsgKey: "{0} failed on the {1} validation",
+	},
+}
+
+// defaultMsgKey 没有定义错误信息的规则使用的key
+const defaultMsgKey = "_default"
+
+// fieldRule 字段上的一条规则
+type fieldRule struct {
+	tag   string
+	param string
+}
+
+// structField 需要校验的字段
+type structField struct {
+	index int
+	name  string
+	// omitempty 值是零值时不校验
+	omitempty bool
+	rules     []fieldRule
+	// nested 字段是struct或者struct的指针，需要校验其中的字段
+	nested bool
+}
+
+// structValidator 使用validate tag校验struct，多个规则以逗号分隔，规则的参数在=之后:
+//  type CreateUserReq struct {
+//  	Name  string `form:"name" validate:"required,max=20"`
+//  	Age   int    `form:"age" validate:"gte=1,lte=150"`
+//  	Email string `form:"email" validate:"omitempty,email"`
+//  	Role  string `form:"role" validate:"oneof=admin user"`
+//  }
+// struct类型的字段会校验其中的字段，内置的规则详见builtinValidations
+// 每个类型第一次校验时解析并检查validate tag，tag错误时返回error而不是ValidationErrors
+type structValidator struct {
+	mu          sync.RWMutex
+	validations map[string]ValidationFunc
+	messages    map[string]map[string]string
+	// fields struct类型的字段信息缓存
+	fields sync.Map
+}
+
+// defaultValidator Context不属于任何app时使用
+var defaultValidator = newStructValidator()
+
+func newStructValidator() *structValidator {
+	return &structValidator{
+		validations: make(map[string]ValidationFunc),
+		messages:    make(map[string]map[string]string),
+	}
+}
+
+// registerValidation 注册校验规则以及各语言的错误信息
+func (sv *structValidator) registerValidation(tag string, fn ValidationFunc, messages map[string]string) {
+	if tag == "" || tag == "omitempty" || strings.ContainsAny(tag, ",= ") {
+		panic("校验规则名称不合法 tag:" + tag)
+	}
+	if fn == nil {
+		panic("校验规则不能是nil tag:" + tag)
+	}
+	for lang := range messages {
+		if _, ok := builtinMessages[lang]; !ok {
+			panic("不支持的语言 lang:" + lang)
+		}
+	}
+
+	sv.mu.Lock()
+	defer sv.mu.Unlock()
+	sv.validations[tag] = fn
+	// 之前解析的结果可能因为规则未定义而失败，需要重新解析
+	sv.fields.Range(func(t, _ interface{}) bool {
+		sv.fields.Delete(t)
+		return true
+	})
+	for lang, message := range messages {
+		if sv.messages[lang] == nil {
+			sv.messages[lang] = make(map[string]string)
+		}
+		sv.messages[lang][tag] = message
+	}
+}
+
+func (sv *structValidator) validation(tag string) (ValidationFunc, bool) {
+	sv.mu.RLock()
+	fn, ok := sv.validations[tag]
+	sv.mu.RUnlock()
+	if ok {
+		return fn, true
+	}
+	fn, ok = builtinValidations[tag]
+	return fn, ok
+}
+
+// message 返回lang语言的错误信息模板
+func (sv *structValidator) message(lang, tag string, field reflect.Value) string {
+	sv.mu.RLock()
+	msg, ok := sv.messages[lang][tag]
+	sv.mu.RUnlock()
+	if ok {
+		return msg
+	}
+	if sizeTags[tag] {
+		tag += "_" + sizeKind(field)
+	}
+	if msg, ok := builtinMessages[lang][tag]; ok {
+		return msg
+	}
+	return builtinMessages[lang][defaultMsgKey]
+}
+
+// Struct 校验dst，dst不是struct或者struct的指针时不校验，校验失败时返回ValidationErrors
+// validate tag错误时返回*ValidateTagError，比如未定义的规则、参数错误以及字段类型不支持规则
+func (sv *structValidator) Struct(dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs ValidationErrors
+	if err := sv.validateStruct(v, v.Type().Name(), &errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (sv *structValidator) validateStruct(v reflect.Value, namespace string, errs *ValidationErrors) error {
+	fields, err := sv.structFields(v.Type())
+	if err != nil {
+		return err
+	}
+	for _, field := range fields {
+		fv := v.Field(field.index)
+		fieldNamespace := namespace + "." + field.name
+		if field.omitempty && !hasValue(fv, "") {
+			continue
+		}
+
+		failed := false
+		for _, rule := range field.rules {
+			fn, _ := sv.validation(rule.tag)
+			value := fv
+			if rule.tag != "required" {
+				// 除required以外的规则校验指针指向的值，nil指针不校验
+				for value.Kind() == reflect.Ptr && !value.IsNil() {
+					value = value.Elem()
+				}
+				if value.Kind() == reflect.Ptr {
+					continue
+				}
+			}
+			if !fn(value, rule.param) {
+				*errs = append(*errs, sv.fieldError(field.name, fieldNamespace, rule, value))
+				failed = true
+				break
+			}
+		}
+
+		if !failed && field.nested {
+			for fv.Kind() == reflect.Ptr && !fv.IsNil() {
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				if err := sv.validateStruct(fv, fieldNamespace, errs); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (sv *structValidator) fieldError(name, namespace string, rule fieldRule, value reflect.Value) FieldError {
+	messages := make(map[string]string, len(langs))
+	for _, lang := range langs {
+		messages[lang] = strings.NewReplacer("{0}", name, "{1}", rule.param).Replace(sv.message(lang, rule.tag, value))
+	}
+	return FieldError{
+		Field:     name,
+		Namespace: namespace,
+		Tag:       rule.tag,
+		Param:     rule.param,
+		messages:  messages,
+	}
+}
+
+// parsedStruct struct解析的结果，validate tag错误时err不为nil
+type parsedStruct struct {
+	fields []structField
+	err    error
+}
+
+// structFields 解析t中需要校验的字段并检查validate tag，结果会被缓存，每个类型只解析一次
+func (sv *structValidator) structFields(t reflect.Type) ([]structField, error) {
+	if parsed, ok := sv.fields.Load(t); ok {
+		return parsed.(parsedStruct).fields, parsed.(parsedStruct).err
+	}
+	fields, err := sv.parseStruct(t)
+	sv.fields.Store(t, parsedStruct{fields: fields, err: err})
+	return fields, err
+}
+
+func (sv *structValidator) parseStruct(t reflect.Type) ([]structField, error) {
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		tag := f.Tag.Get(validateTagName)
+		if tag == "-" {
+			continue
+		}
+
+		field := structField{index: i, name: validationFieldName(f)}
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		field.nested = ft.Kind() == reflect.Struct && ft.PkgPath() != "time"
+
+		for _, r := range strings.Split(tag, ",") {
+			r = strings.TrimSpace(r)
+			if r == "" {
+				continue
+			}
+			if r == "omitempty" {
+				field.omitempty = true
+				continue
+			}
+			rule := fieldRule{tag: r}
+			if eq := strings.IndexByte(r, '='); eq >= 0 {
+				rule.tag, rule.param = r[:eq], r[eq+1:]
+			}
+			if err := sv.checkRule(rule, ft); err != nil {
+				return nil, &ValidateTagError{Struct: t.String(), Field: f.Name, Err: err}
+			}
+			field.rules = append(field.rules, rule)
+		}
+		if len(field.rules) == 0 && !field.nested {
+			continue
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// checkRule 检查规则是否定义以及内置规则的参数和字段类型，自定义的规则只检查是否定义
+func (sv *structValidator) checkRule(rule fieldRule, t reflect.Type) error {
+	sv.mu.RLock()
+	_, custom := sv.validations[rule.tag]
+	sv.mu.RUnlock()
+	if custom {
+		return nil
+	}
+	if _, ok := builtinValidations[rule.tag]; !ok {
+		return fmt.Errorf("未定义的校验规则 tag:%s", rule.tag)
+	}
+	// interface类型的字段只能在校验时确定类型，类型不支持时校验失败
+	if check, ok := builtinRuleChecks[rule.tag]; ok && t.Kind() != reflect.Interface {
+		return check(t, rule.param)
+	}
+	return nil
+}
+
+func checkSizeRule(t reflect.Type, param string) error {
+	if !isSizeKind(t.Kind()) {
+		return fmt.Errorf("类型%s不支持比较大小", t)
+	}
+	if _, err := strconv.ParseFloat(param, 64); err != nil {
+		return fmt.Errorf("参数必须是数值 param:%s", param)
+	}
+	return nil
+}
+
+func checkEqualRule(t reflect.Type, param string) error {
+	switch t.Kind() {
+	case reflect.String:
+		return nil
+	case reflect.Bool:
+		if _, err := strconv.ParseBool(param); err != nil {
+			return fmt.Errorf("参数必须是bool值 param:%s", param)
+		}
+		return nil
+	}
+	return checkSizeRule(t, param)
+}
+
+func checkOneOfRule(t reflect.Type, param string) error {
+	if strings.TrimSpace(param) == "" {
+		return fmt.Errorf("oneof的参数不能为空")
+	}
+	for _, p := range strings.Fields(param) {
+		if err := checkEqualRule(t, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validationFieldName 错误信息中的字段名称，依次使用json、form、path、header、cookie tag
+func validationFieldName(field reflect.StructField) string {
+	for _, tagName := range []string{"json", "form", "path", "header", "cookie"} {
+		name := strings.SplitN(field.Tag.Get(tagName), ",", 2)[0]
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// hasValue 值不是零值，指针、slice、map、interface不是nil
+func hasValue(v reflect.Value, _ string) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface, reflect.Chan, reflect.Func:
+		return !v.IsNil()
+	case reflect.Invalid:
+		return false
+	}
+	return !v.IsZero()
+}
+
+// sizeKind 返回size类规则比较的是字符数(string)、元素个数(items)还是数值(number)
+func sizeKind(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return "items"
+	}
+	return "number"
+}
+
+// isSizeKind 可以使用len、min、max等规则比较大小的类型
+func isSizeKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// sizeValidation 使用compareSize的结果判断是否通过，不能比较大小时校验失败
+func sizeValidation(pass func(c int) bool) ValidationFunc {
+	return func(v reflect.Value, param string) bool {
+		c, ok := compareSize(v, param)
+		return ok && pass(c)
+	}
+}
+
+// compareSize 比较v的大小与param，小于、等于、大于分别返回-1、0、1
+// v的类型不支持比较大小或者param不是数值时ok为false
+func compareSize(v reflect.Value, param string) (c int, ok bool) {
+	var size float64
+	switch v.Kind() {
+	case reflect.String:
+		size = float64(utf8.RuneCountInString(v.String()))
+	case reflect.Slice, reflect.Map, reflect.Array:
+		size = float64(v.Len())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		size = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		size = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		size = v.Float()
+	default:
+		return 0, false
+	}
+
+	p, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return 0, false
+	}
+	switch {
+	case size < p:
+		return -1, true
+	case size > p:
+		return 1, true
+	}
+	return 0, true
+}
+
+func isEqual(v reflect.Value, param string) bool {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String() == param
+	case reflect.Bool:
+		b, err := strconv.ParseBool(param)
+		return err == nil && v.Bool() == b
+	}
+	c, ok := compareSize(v, param)
+	return ok && c == 0
+}
+
+func isOneOf(v reflect.Value, param string) bool {
+	for _, p := range strings.Fields(param) {
+		if isEqual(v, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func isEmail(v reflect.Value, _ string) bool {
+	if v.Kind() != reflect.String {
+		return false
+	}
+	addr, err := mail.ParseAddress(v.String())
+	return err == nil && addr.Address == v.String() && strings.Contains(addr.Address[strings.LastIndexByte(addr.Address, '@'):], ".")
+}
+
+func isURL(v reflect.Value, _ string) bool {
+	if v.Kind() != reflect.String {
+		return false
+	}
+	u, err := url.ParseRequestURI(v.String())
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+func isNumeric(v reflect.Value, _ string) bool {
+	if v.Kind() != reflect.String {
+		return sizeKind(v) == "number"
+	}
+	_, err := strconv.ParseFloat(v.String(), 64)
+	return err == nil
+}
+
+func matchRunes(v reflect.Value, match func(r rune) bool) bool {
+	if v.Kind() != reflect.String || v.String() == "" {
+		return false
+	}
+	for _, r := range v.String() {
+		if !match(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// RegisterValidation 注册自定义校验规则，messages是各语言的错误信息，{0}是字段名称，{1}是规则的参数
+//  app.RegisterValidation("mobile", func(field reflect.Value, param string) bool {
+//  	return mobileRegexp.MatchString(field.String())
+//  }, map[string]string{
+//  	gwf.LangZh: "{0}必须是有效的手机号",
+//  	gwf.LangEn: "{0} must be a valid mobile number",
+//  })
+// 与内置规则同名时会覆盖内置规则，请在处理请求之前调用
+func (app *Application) RegisterValidation(tag string, fn ValidationFunc, messages map[string]string) {
+	app.structValidator().registerValidation(tag, fn, messages)
+}
+
+func (app *Application) structValidator() *structValidator {
+	app.validatorOnce.Do(func() {
+		app.validator = newStructValidator()
+	})
+	return app.validator
+}
+
+// Validate 使用validate tag校验dst，校验失败时返回ValidationErrors，validate tag错误时返回*ValidateTagError
+// Bind系列函数绑定参数之后会自动校验，不需要再调用此方法
+func (c *Context) Validate(dst interface{}) error {
+	if c.app == nil {
+		return defaultValidator.Struct(dst)
+	}
+	return c.app.structValidator().Struct(dst)
+}
+
+// Lang 根据请求的Accept-Language返回错误信息的语言，默认是中文
+func (c *Context) Lang() string {
+	for _, part := range strings.Split(c.Request.Header.Get("Accept-Language"), ",") {
+		tag := strings.ToLower(strings.TrimSpace(strings.SplitN(part, ";", 2)[0]))
+		for _, lang := range langs {
+			if tag == lang || strings.HasPrefix(tag, lang+"-") {
+				return lang
+			}
+		}
+	}
+	return langs[0]
+}
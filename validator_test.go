@@ -0,0 +1,220 @@
+package gwf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type signupReq struct {
+	Name  string `form:"name" json:"name" validate:"required,min=2,max=10"`
+	Age   int    `form:"age" json:"age" validate:"gte=1,lte=150"`
+	Email string `form:"email" json:"email" validate:"omitempty,email"`
+	Role  string `form:"role" json:"role" validate:"oneof=admin user"`
+}
+
+func TestBindValidate(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/signup?name=tom&age=18&role=admin", nil)
+	c := newCtx(nil, r)
+	req := signupReq{}
+	assert.Nil(t, c.BindQuery(&req))
+
+	r, _ = http.NewRequest("GET", "/signup?age=200&email=x&role=root", nil)
+	c = newCtx(nil, r)
+	err := c.BindQuery(&signupReq{})
+	errs, ok := err.(ValidationErrors)
+	if assert.True(t, ok) && assert.Len(t, errs, 4) {
+		assert.Equal(t, "name", errs[0].Field)
+		assert.Equal(t, "signupReq.name", errs[0].Namespace)
+		assert.Equal(t, "required", errs[0].Tag)
+		assert.Equal(t, "name为必填字段", errs[0].Message(LangZh))
+		assert.Equal(t, "name is a required field", errs[0].Message(LangEn))
+		assert.Equal(t, "lte", errs[1].Tag)
+		assert.Equal(t, "150", errs[1].Param)
+		assert.Equal(t, "email", errs[2].Tag)
+		assert.Equal(t, "oneof", errs[3].Tag)
+	}
+	assert.True(t, strings.HasPrefix(err.Error(), "name为必填字段; "))
+
+	r, _ = http.NewRequest("POST", "/signup", strings.NewReader(`{"name":"t","age":1,"role":"user"}`))
+	r.Header.Set("Content-Type", "application/json")
+	c = newCtx(nil, r)
+	err = c.ShouldBind(&signupReq{})
+	errs, ok = err.(ValidationErrors)
+	if assert.True(t, ok) && assert.Len(t, errs, 1) {
+		assert.Equal(t, "min", errs[0].Tag)
+	}
+}
+
+type mobileReq struct {
+	Mobile string `form:"mobile" validate:"mobile"`
+}
+
+func TestRegisterValidation(t *testing.T) {
+	app := newTestApplication()
+	app.RegisterValidation("mobile", func(field reflect.Value, param string) bool {
+		return len(field.String()) == 11
+	}, map[string]string{
+		LangZh: "{0}必须是有效的手机号",
+		LangEn: "{0} must be a valid mobile number",
+	})
+
+	r, _ := http.NewRequest("GET", "/?mobile=123", nil)
+	c := newCtx(app, r)
+	err := c.BindQuery(&mobileReq{})
+	errs, ok := err.(ValidationErrors)
+	if assert.True(t, ok) && assert.Len(t, errs, 1) {
+		assert.Equal(t, "mobile必须是有效的手机号", errs[0].Message(LangZh))
+		assert.Equal(t, "mobile must be a valid mobile number", errs[0].Message(LangEn))
+	}
+
+	r, _ = http.NewRequest("GET", "/?mobile=13800000000", nil)
+	assert.Nil(t, newCtx(app, r).BindQuery(&mobileReq{}))
+
+	assert.Panics(t, func() {
+		app.RegisterValidation("mobile2", func(field reflect.Value, param string) bool { return true }, map[string]string{"fr": "{0}"})
+	})
+	err = newCtx(app, r).Validate(&struct {
+		Name string `validate:"unknown"`
+	}{})
+	if assert.NotNil(t, err) {
+		_, ok = err.(ValidationErrors)
+		assert.False(t, ok)
+		assert.Contains(t, err.Error(), "未定义的校验规则 tag:unknown")
+	}
+}
+
+func TestValidateTagError(t *testing.T) {
+	// validate tag错误时返回error，不能panic
+	tests := []interface{}{
+		&struct {
+			Enabled bool `validate:"max=1"`
+		}{},
+		&struct {
+			Name string `validate:"max=abc"`
+		}{},
+		&struct {
+			Enabled bool `validate:"eq=yes"`
+		}{},
+		&struct {
+			Age int `validate:"oneof=1 a"`
+		}{},
+		&struct {
+			Inner struct {
+				Name string `validate:"required,unknown"`
+			}
+		}{},
+	}
+	for _, dst := range tests {
+		var err error
+		assert.NotPanics(t, func() { err = defaultValidator.Struct(dst) })
+		_, ok := err.(*ValidateTagError)
+		assert.True(t, ok, "%T", dst)
+		// 同一类型再次校验时返回缓存的错误
+		assert.Equal(t, err, defaultValidator.Struct(dst))
+	}
+
+	// interface类型的字段只能在校验时确定类型，类型不支持时校验失败
+	dst := &struct {
+		Value interface{} `validate:"max=1"`
+	}{Value: true}
+	err := defaultValidator.Struct(dst)
+	errs, ok := err.(ValidationErrors)
+	if assert.True(t, ok) && assert.Len(t, errs, 1) {
+		assert.Equal(t, "max", errs[0].Tag)
+	}
+
+	app := newTestApplication()
+	app.GET("/tag", func(c *Context) {
+		err := c.Validate(&struct {
+			Name string `validate:"min=x"`
+		}{})
+		c.String(http.StatusInternalServerError, err.Error())
+	})
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/tag", nil)
+	assert.NotPanics(t, func() { app.ServeHTTP(w, r) })
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+type signupController struct {
+	*Controller
+}
+
+func (controller *signupController) Init() {}
+
+func (controller *signupController) CreateAction(c *Context, req *signupReq) error {
+	c.String(http.StatusOK, req.Name)
+	return nil
+}
+
+func TestTypedActionValidate(t *testing.T) {
+	app := newTestApplication()
+	app.RegisterDynamicRouter(&signupController{}, "", []string{"GET"})
+	url := "/github.com/panda-win/gwf/signup/create"
+
+	tests := []struct {
+		query string
+		lang  string
+		code  int
+		body  string
+	}{
+		{"?name=tom&age=1&role=user", "", http.StatusOK, "tom"},
+		{"?age=1&role=user", "", http.StatusBadRequest, `{"code":400,"message":"name为必填字段"}`},
+		{"?age=1&role=user", "en-US,en;q=0.9", http.StatusBadRequest, `{"code":400,"message":"name is a required field"}`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", url+tt.query, nil)
+		r.Header.Set("Accept-Language", tt.lang)
+		app.ServeHTTP(w, r)
+		assert.Equal(t, tt.code, w.Code, tt.query)
+		assert.Equal(t, tt.body, w.Body.String(), tt.query)
+	}
+}
+
+type address struct {
+	City string `json:"city" validate:"required"`
+}
+
+type profileReq struct {
+	Tags    []string `json:"tags" validate:"min=1,max=3"`
+	Score   *float64 `json:"score" validate:"omitempty,gt=0,lte=5"`
+	Site    string   `json:"site" validate:"omitempty,url"`
+	Code    string   `json:"code" validate:"len=4,alphanum"`
+	Address *address `json:"address" validate:"required"`
+	Backup  address  `json:"backup"`
+}
+
+func TestStructValidator(t *testing.T) {
+	score := 6.0
+	req := profileReq{
+		Score:   &score,
+		Site:    "example.com",
+		Code:    "ab-1",
+		Address: &address{},
+		Backup:  address{City: "beijing"},
+	}
+	err := defaultValidator.Struct(&req)
+	errs, ok := err.(ValidationErrors)
+	if assert.True(t, ok) && assert.Len(t, errs, 5) {
+		assert.Equal(t, "tags必须至少包含1项", errs[0].Message(LangZh))
+		assert.Equal(t, "score must be 5 or less", errs[1].Message(LangEn))
+		assert.Equal(t, "url", errs[2].Tag)
+		assert.Equal(t, "alphanum", errs[3].Tag)
+		assert.Equal(t, "profileReq.address.city", errs[4].Namespace)
+	}
+
+	req = profileReq{
+		Tags:    []string{"go"},
+		Code:    "ab12",
+		Address: &address{City: "shanghai"},
+		Backup:  address{City: "beijing"},
+	}
+	assert.Nil(t, defaultValidator.Struct(&req))
+	assert.Nil(t, defaultValidator.Struct(map[string]string{}))
+}